@@ -7,10 +7,12 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/nokka/d2-chatbot/internal/bnetd"
 	"github.com/nokka/d2-chatbot/internal/client"
+	"github.com/nokka/d2-chatbot/internal/config"
 	"github.com/nokka/d2-chatbot/internal/inmem"
 	"github.com/nokka/d2-chatbot/internal/mysql"
 	"github.com/nokka/d2-chatbot/pkg/env"
@@ -22,47 +24,24 @@ func main() {
 		mysqlHost     = env.String("MYSQL_HOST", "127.0.0.1:3306")
 		mysqlUser     = env.String("MYSQL_USER", "chat_user")
 		mysqlPw       = env.String("MYSQL_PASSWORD", "")
-		chatUsername  = env.String("CHAT_USERNAME", "chat")
-		chatPassword  = env.String("CHAT_PASSWORD", "")
-		tradeUsername = env.String("TRADE_USERNAME", "trade")
-		tradePassword = env.String("TRADE_PASSWORD", "")
-		hcUsername    = env.String("HC_USERNAME", "hc")
-		hcPassword    = env.String("HC_PASSWORD", "")
-		bnetdLog      = env.String("BNETD_LOG", "")
+		configFile    = env.String("CONFIG_FILE", "channels.yml")
 	)
 
-	if serverAddress == "" {
-		log.Println("server address not set")
-		os.Exit(0)
-	}
-
-	if chatUsername == "" {
-		log.Println("chat username not set")
-		os.Exit(0)
-	}
-
-	if chatPassword == "" {
-		log.Println("chat password not set")
-		os.Exit(0)
-	}
-
-	if tradeUsername == "" {
-		log.Println("trade username not set")
-		os.Exit(0)
-	}
-
-	if tradePassword == "" {
-		log.Println("trade password not set")
+	statsInterval, err := env.Int("STATS_INTERVAL", 300)
+	if err != nil {
+		log.Println("failed to parse stats interval", err)
 		os.Exit(0)
 	}
 
-	if hcUsername == "" {
-		log.Println("hc username not set")
+	if serverAddress == "" {
+		log.Println("server address not set")
 		os.Exit(0)
 	}
 
-	if hcPassword == "" {
-		log.Println("hc password not set")
+	// Channel registry.
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		log.Println("failed to load config", err)
 		os.Exit(0)
 	}
 
@@ -86,90 +65,119 @@ func main() {
 	// Repositories
 	inmemRepository := inmem.NewSubscriberRepository()
 	subscriberRepository := mysql.NewSubscriberRepository(pool)
+	moderationRepository := mysql.NewModerationRepository(pool)
+	presenceRepository := mysql.NewPresenceRepository(pool)
+	tradeRepository := mysql.NewTradeRepository(pool)
+	historyRepository := mysql.NewHistoryRepository(pool)
 
-	// Get moderators to sync.
-	mods, err := subscriberRepository.FindModerators()
+	// Get roles to sync.
+	roles, err := subscriberRepository.FindRoles()
 	if err != nil {
-		log.Println("failed to sync moderators")
+		log.Println("failed to sync roles")
 		os.Exit(0)
 	}
 
-	inmemRepository.SyncModerators(mods)
-
-	// Chat bot connection.
-	cb := client.New(
-		serverAddress,
-		chatUsername,
-		chatPassword,
-		inmemRepository,
-		subscriberRepository,
-	)
+	inmemRepository.SyncRoles(roles)
 
-	// Sync the chat bot in memory store with the persistent store.
-	if err := cb.Sync(); err != nil {
-		log.Println("failed to sync chat data", err)
+	// Get game announcement filters to sync.
+	filters, err := subscriberRepository.FindGameFilters()
+	if err != nil {
+		log.Println("failed to sync game filters")
 		os.Exit(0)
 	}
 
-	// Make sure the sync has run before we open for incoming traffic.
-	if err := cb.Open(); err != nil {
-		log.Println("failed to open chat connection", err)
+	inmemRepository.SyncGameFilters(filters)
+
+	// Get ignores to sync.
+	ignores, err := subscriberRepository.FindIgnores()
+	if err != nil {
+		log.Println("failed to sync ignores")
 		os.Exit(0)
 	}
 
-	// Trade bot connection.
-	tb := client.New(
-		serverAddress,
-		tradeUsername,
-		tradePassword,
-		inmemRepository,
-		subscriberRepository,
-	)
+	inmemRepository.SyncIgnores(ignores)
 
-	// Sync the trade bot in memory store with the persistent store.
-	if err := tb.Sync(); err != nil {
-		log.Println("failed to sync trade data", err)
-		os.Exit(0)
+	// Server events read by the watcher, published for the bots to consume.
+	events := bnetd.NewStream()
+
+	// Bots never hint each other.
+	var bots []string
+	for _, ch := range cfg.Channels {
+		bots = append(bots, ch.Account)
 	}
 
-	// Make sure the sync has run before we open for incoming traffic.
-	if err := tb.Open(); err != nil {
-		log.Println("failed to open trade connection", err)
-		os.Exit(0)
+	// Set up one bot per configured channel.
+	clients := make(map[string]*client.Client)
+	for _, ch := range cfg.Channels {
+		opts := clientOptions(ch.Options)
+		opts.Bots = bots
+
+		c := client.New(
+			serverAddress,
+			ch.ID,
+			ch.Account,
+			ch.Password,
+			opts,
+			inmemRepository,
+			subscriberRepository,
+			moderationRepository,
+			presenceRepository,
+			tradeRepository,
+			historyRepository,
+		)
+
+		// Sync the bot in memory store with the persistent store.
+		if err := c.Sync(); err != nil {
+			log.Printf("failed to sync %s data %s", ch.ID, err)
+			os.Exit(0)
+		}
+
+		// Let the bot announce games created on the server.
+		events.Subscribe(c.HandleEvent)
+
+		clients[ch.ID] = c
 	}
 
-	// HC bot connection.
-	hc := client.New(
-		serverAddress,
-		hcUsername,
-		hcPassword,
-		inmemRepository,
-		subscriberRepository,
-	)
+	// Source of subscribers online state and other server events.
+	source := presenceSource(cfg.Presence)
 
-	// Sync the hc bot in memory store with the persistent store.
-	if err := hc.Sync(); err != nil {
-		log.Println("failed to sync hc data", err)
+	// Correct the online state of all subscribers, which may be stale after a restart.
+	if ps, ok := source.(bnetd.PresenceSource); ok {
+		err = bnetd.Reconcile(ps, inmemRepository, subscriberRepository)
+	} else if cfg.Presence.File != "" {
+		err = bnetd.Reconcile(bnetd.NewLogScanner(cfg.Presence.File), inmemRepository, subscriberRepository)
+	} else {
+		log.Printf("%s source can't reconcile online state, skipping", cfg.Presence.Source)
+	}
+	if err != nil {
+		log.Println("failed to reconcile online state", err)
 		os.Exit(0)
 	}
 
-	// Make sure the sync has run before we open for incoming traffic.
-	if err := hc.Open(); err != nil {
-		log.Println("failed to open hc connection", err)
-		os.Exit(0)
+	// Make sure the sync and reconciliation have run before we open for incoming traffic.
+	for _, ch := range cfg.Channels {
+		if err := clients[ch.ID].Open(); err != nil {
+			log.Printf("failed to open %s connection %s", ch.ID, err)
+			os.Exit(0)
+		}
 	}
 
-	// Open file watcher for bnetd.log to listen for changes in subscribers online state.
+	// Periodically log the state of every bot connection.
+	go logStats(clients, statsInterval)
+
+	// Open watcher to listen for changes in subscribers online state.
 	w := bnetd.NewWatcher(
-		bnetdLog,
+		source,
 		inmemRepository,
 		subscriberRepository,
+		presenceRepository,
+		events,
 	)
 
 	// Start bnetd watcher.
 	err = w.Start()
 	if err != nil {
-		log.Println("failed to open bnetd.log watcher", err)
+		log.Println("failed to start bnetd watcher", err)
 		os.Exit(0)
 	}
 
@@ -186,6 +194,58 @@ func main() {
 	// Listen for errors indefinitely.
 	if err := <-errorChannel; err != nil {
 		log.Println(err)
+
+		// Stop the source, persisting how far into bnetd.log we got to pick up from there on restart.
+		if err := w.Stop(); err != nil {
+			log.Println("failed to stop bnetd watcher", err)
+		}
+
 		os.Exit(1)
 	}
 }
+
+// presenceSource returns the configured source of online state.
+func presenceSource(p config.Presence) bnetd.Source {
+	switch p.Source {
+	case config.SourceSyslog:
+		return bnetd.NewSyslogSource(p.SyslogNetwork, p.SyslogAddress)
+	case config.SourceStatus:
+		return bnetd.NewStatusSource(p.StatusFile, p.PollInterval)
+	default:
+		return bnetd.NewFileSource(p.File, p.Position)
+	}
+}
+
+// clientOptions maps the channel options from the config to client options.
+func clientOptions(opts config.Options) client.Options {
+	return client.Options{
+		WhisperRate:      opts.WhisperRate,
+		WhisperBurst:     opts.WhisperBurst,
+		WhisperQueueSize: opts.WhisperQueueSize,
+		RateLimit:        opts.RateLimit,
+		RateWindow:       opts.RateWindow,
+		SlowMode:         opts.SlowMode,
+		LFGExpiry:        opts.LFGExpiry,
+		TradeListings:    opts.TradeListings,
+		ListingExpiry:    opts.ListingExpiry,
+		HistorySize:      opts.HistorySize,
+		HistoryRetention: opts.HistoryRetention,
+	}
+}
+
+// logStats logs connection state and whisper queue counters of all clients every interval seconds.
+func logStats(clients map[string]*client.Client, interval int) {
+	if interval <= 0 {
+		return
+	}
+
+	for range time.Tick(time.Duration(interval) * time.Second) {
+		for id, c := range clients {
+			stats := c.QueueStats()
+			log.Printf(
+				"%s: state=%s reconnects=%d queued=%d sent=%d failed=%d dropped=%d depth=%d",
+				id, c.State(), c.ReconnectAttempts(), stats.Queued, stats.Sent, stats.Failed, stats.Dropped, stats.Depth,
+			)
+		}
+	}
+}
@@ -2,8 +2,11 @@ CREATE DATABASE chat;
 CREATE USER 'chat_user'@'%' IDENTIFIED WITH mysql_native_password BY 'supersecret';
 GRANT SELECT, INSERT, UPDATE, DELETE ON chat.* TO 'chat_user'@'%';
 
-CREATE TABLE chat.moderators (
-account VARCHAR(50) PRIMARY KEY
+CREATE TABLE chat.roles (
+account VARCHAR(50) NOT NULL,
+chat VARCHAR(15) NOT NULL,
+role VARCHAR(20) NOT NULL,
+PRIMARY KEY(account, chat, role)
 );
 
 CREATE TABLE chat.subscribers (
@@ -11,6 +14,81 @@ account VARCHAR(50) NOT NULL,
 chat VARCHAR(15) NOT NULL,
 online BOOLEAN NOT NULL DEFAULT TRUE,
 banned_until TIMESTAMP NULL,
+banned_permanently BOOLEAN NOT NULL DEFAULT FALSE,
+digest BOOLEAN NOT NULL DEFAULT FALSE,
+mentions_disabled BOOLEAN NOT NULL DEFAULT FALSE,
 subscribed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 PRIMARY KEY(account, chat)
 );
+
+CREATE TABLE chat.moderation_actions (
+id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+actor VARCHAR(50) NOT NULL,
+target VARCHAR(50) NOT NULL,
+chat VARCHAR(15) NOT NULL,
+action VARCHAR(20) NOT NULL,
+reason VARCHAR(255) NOT NULL DEFAULT '',
+expires_at TIMESTAMP NULL,
+created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+INDEX (target, created_at)
+);
+
+CREATE TABLE chat.presence (
+account VARCHAR(50) PRIMARY KEY,
+last_login TIMESTAMP NULL,
+last_logout TIMESTAMP NULL
+);
+
+CREATE TABLE chat.game_filters (
+account VARCHAR(50) NOT NULL,
+chat VARCHAR(15) NOT NULL,
+pattern VARCHAR(32) NOT NULL,
+PRIMARY KEY(account, chat, pattern)
+);
+
+CREATE TABLE chat.trade_listings (
+id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+account VARCHAR(50) NOT NULL,
+chat VARCHAR(15) NOT NULL,
+type VARCHAR(3) NOT NULL,
+items VARCHAR(255) NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+expires_at TIMESTAMP NOT NULL,
+UNIQUE KEY (account, chat, type, items),
+INDEX (chat, expires_at)
+);
+
+CREATE TABLE chat.watches (
+account VARCHAR(50) NOT NULL,
+chat VARCHAR(15) NOT NULL,
+keyword VARCHAR(32) NOT NULL,
+PRIMARY KEY(account, chat, keyword),
+INDEX (chat)
+);
+
+CREATE TABLE chat.messages (
+id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+chat VARCHAR(15) NOT NULL,
+account VARCHAR(50) NOT NULL,
+text VARCHAR(255) NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+INDEX (chat, created_at)
+);
+
+CREATE TABLE chat.mentions (
+id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+account VARCHAR(50) NOT NULL,
+chat VARCHAR(15) NOT NULL,
+sender VARCHAR(50) NOT NULL,
+text VARCHAR(255) NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+INDEX (account, chat),
+INDEX (chat, created_at)
+);
+
+CREATE TABLE chat.ignores (
+account VARCHAR(50) NOT NULL,
+chat VARCHAR(15) NOT NULL,
+ignored VARCHAR(50) NOT NULL,
+PRIMARY KEY(account, chat, ignored)
+);
@@ -3,18 +3,47 @@ package bnetd
 import (
 	"regexp"
 	"strings"
+	"time"
 )
 
 // Compile the regex once.
 var r = regexp.MustCompile(`(?i)^.*\s"([a-z0-9_\-]+)"\s([a-z]+\s[a-z]{2,3})`)
 
+// Entry written by PvpGN when the server starts, such as "main: PvPGN version 1.99.7.2.1 process 1234".
+var serverStartRegex = regexp.MustCompile(`(?i)\s(pvpgn|bnetd) version \S+ process \d+`)
+
+// Entry written by PvpGN when the server shuts down, such as "server_process: the server is shutting down (12 connections left)".
+var serverShutdownRegex = regexp.MustCompile(`(?i)\sthe server is shutting down`)
+
+// Entry written when an account is created, such as "_client_createacctreq2: [28] account "nokka" created".
+var accountCreateRegex = regexp.MustCompile(`(?i)\saccount\s"([a-z0-9_\-]+)"\screated`)
+
+// Entry written when a game is created, such as "game_create: game "baal-12" (pass "") type 0(normal) startver 0 created".
+var gameCreateRegex = regexp.MustCompile(`(?i)\sgame\s"([^"]+)"\s\(pass\s"([^"]*)"\).*\screated`)
+
+// Entry written when an account joins or leaves a game, such as "game_add_player: [28] "nokka" joined game "baal-12"".
+var gameRegex = regexp.MustCompile(`(?i)\s"([a-z0-9_\-]+)"\s(joined|left)\sgame\s"([^"]+)"`)
+
+// Entry written when an account joins a channel, such as "channel_add_connection: [28] "nokka" joined channel "Diablo II"".
+var channelJoinRegex = regexp.MustCompile(`(?i)\s"([a-z0-9_\-]+)"\sjoined\schannel\s"([^"]+)"`)
+
+// Timestamp at the start of every entry, such as "Aug 28 08:25:22".
+var timestampRegex = regexp.MustCompile(`^([A-Z][a-z]{2}\s+\d{1,2}\s\d{2}:\d{2}:\d{2})`)
+
+// Layout of the entry timestamp, it doesn't contain a year.
+const timestampLayout = "Jan _2 15:04:05"
+
 // Decoder decodes bnet.log entries.
-type decoder struct{}
+type decoder struct {
+	// now returns the current time, used to add the year to entry timestamps.
+	now func() time.Time
+}
 
 // StatusChange is used to represent a valid status change.
 type StatusChange struct {
 	Account string
 	Online  bool
+	Time    time.Time
 }
 
 const (
@@ -44,7 +73,91 @@ func (d decoder) Decode(data string) (*StatusChange, bool) {
 	change := &StatusChange{
 		Account: strings.ToLower(matches[account]),
 		Online:  online,
+		Time:    d.timestamp(data),
 	}
 
 	return change, true
 }
+
+// DecodeEvent decodes any entry describing something that happened on the server.
+func (d decoder) DecodeEvent(data string) (*Event, bool) {
+	if change, valid := d.Decode(data); valid {
+		return statusEvent(change), true
+	}
+
+	event := &Event{Time: d.timestamp(data)}
+
+	if d.ServerStarted(data) {
+		event.Type = EventServerStart
+		return event, true
+	}
+
+	if serverShutdownRegex.MatchString(data) {
+		event.Type = EventServerShutdown
+		return event, true
+	}
+
+	if matches := accountCreateRegex.FindStringSubmatch(data); len(matches) == 2 {
+		event.Type = EventAccountCreate
+		event.Account = strings.ToLower(matches[1])
+		return event, true
+	}
+
+	if matches := gameCreateRegex.FindStringSubmatch(data); len(matches) == 3 {
+		event.Type = EventGameCreate
+		event.Game = matches[1]
+		event.Private = matches[2] != ""
+		return event, true
+	}
+
+	if matches := gameRegex.FindStringSubmatch(data); len(matches) == 4 {
+		event.Type = EventGameJoin
+		if strings.EqualFold(matches[2], "left") {
+			event.Type = EventGameLeave
+		}
+		event.Account = strings.ToLower(matches[1])
+		event.Game = matches[3]
+		return event, true
+	}
+
+	if matches := channelJoinRegex.FindStringSubmatch(data); len(matches) == 3 {
+		event.Type = EventChannelJoin
+		event.Account = strings.ToLower(matches[1])
+		event.Channel = matches[2]
+		return event, true
+	}
+
+	return nil, false
+}
+
+// ServerStarted reports whether the entry was written when the server started.
+func (d decoder) ServerStarted(data string) bool {
+	return serverStartRegex.MatchString(data)
+}
+
+// timestamp parses the timestamp of an entry, falling back to the current time.
+func (d decoder) timestamp(data string) time.Time {
+	now := time.Now()
+	if d.now != nil {
+		now = d.now()
+	}
+
+	matches := timestampRegex.FindStringSubmatch(data)
+	if len(matches) != 2 {
+		return now
+	}
+
+	t, err := time.ParseInLocation(timestampLayout, matches[1], now.Location())
+	if err != nil {
+		return now
+	}
+
+	// The entry is from this year, unless that puts it in the future,
+	// which happens when reading last year's entries in january.
+	t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+
+	return t
+}
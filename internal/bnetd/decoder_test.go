@@ -3,10 +3,12 @@ package bnetd
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestDecode(t *testing.T) {
-	decoder := decoder{}
+	now := time.Date(2020, 8, 28, 12, 0, 0, 0, time.UTC)
+	decoder := decoder{now: func() time.Time { return now }}
 
 	tests := []struct {
 		name   string
@@ -20,6 +22,7 @@ func TestDecode(t *testing.T) {
 			change: &StatusChange{
 				Account: "nokka",
 				Online:  true,
+				Time:    time.Date(2020, 8, 28, 8, 25, 22, 0, time.UTC),
 			},
 			valid: true,
 		},
@@ -29,6 +32,7 @@ func TestDecode(t *testing.T) {
 			change: &StatusChange{
 				Account: "nokka",
 				Online:  false,
+				Time:    time.Date(2020, 8, 28, 9, 1, 48, 0, time.UTC),
 			},
 			valid: true,
 		},
@@ -44,6 +48,7 @@ func TestDecode(t *testing.T) {
 			change: &StatusChange{
 				Account: "discord",
 				Online:  true,
+				Time:    time.Date(2020, 8, 28, 8, 15, 5, 0, time.UTC),
 			},
 			valid: true,
 		},
@@ -53,6 +58,27 @@ func TestDecode(t *testing.T) {
 			change: &StatusChange{
 				Account: "discord",
 				Online:  false,
+				Time:    time.Date(2020, 8, 28, 9, 15, 35, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "login last year",
+			input: "Dec 31 23:59:59 [info ] _client_loginreq2: [28] \"nokka\" logged in (correct password)",
+			change: &StatusChange{
+				Account: "nokka",
+				Online:  true,
+				Time:    time.Date(2019, 12, 31, 23, 59, 59, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "login without timestamp",
+			input: "_client_loginreq2: [28] \"nokka\" logged in (correct password)",
+			change: &StatusChange{
+				Account: "nokka",
+				Online:  true,
+				Time:    now,
 			},
 			valid: true,
 		},
@@ -72,3 +98,172 @@ func TestDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestServerStarted(t *testing.T) {
+	decoder := decoder{}
+
+	tests := []struct {
+		name    string
+		input   string
+		started bool
+	}{
+		{
+			name:    "pvpgn start",
+			input:   "Aug 28 07:00:01 [info ] main: PvPGN version 1.99.7.2.1 process 1234",
+			started: true,
+		},
+		{
+			name:    "bnetd start",
+			input:   "Aug 28 07:00:01 [info ] main: bnetd version 1.8.5 process 1234",
+			started: true,
+		},
+		{
+			name:    "user login",
+			input:   "Aug 28 08:25:22 [info ] _client_loginreq2: [28] \"nokka\" logged in (correct password)",
+			started: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if started := decoder.ServerStarted(tt.input); started != tt.started {
+				t.Fatalf("expected started = %v; got = %v", tt.started, started)
+			}
+		})
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	now := time.Date(2020, 8, 28, 12, 0, 0, 0, time.UTC)
+	decoder := decoder{now: func() time.Time { return now }}
+
+	tests := []struct {
+		name  string
+		input string
+		event *Event
+		valid bool
+	}{
+		{
+			name:  "user login",
+			input: "Aug 28 08:25:22 [info ] _client_loginreq2: [28] \"nokka\" logged in (correct password)",
+			event: &Event{
+				Type:    EventLogin,
+				Account: "nokka",
+				Time:    time.Date(2020, 8, 28, 8, 25, 22, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "user logout",
+			input: "Aug 28 09:01:48 [info ] conn_destroy: [28] \"nokka\" logged out",
+			event: &Event{
+				Type:    EventLogout,
+				Account: "nokka",
+				Time:    time.Date(2020, 8, 28, 9, 1, 48, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "account create",
+			input: "Aug 28 08:20:01 [info ] _client_createacctreq2: [28] account \"Nokka\" created",
+			event: &Event{
+				Type:    EventAccountCreate,
+				Account: "nokka",
+				Time:    time.Date(2020, 8, 28, 8, 20, 1, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "public game create",
+			input: "Aug 28 08:30:10 [info ] game_create: game \"baal-12\" (pass \"\") type 0(normal) startver 0 created",
+			event: &Event{
+				Type: EventGameCreate,
+				Game: "baal-12",
+				Time: time.Date(2020, 8, 28, 8, 30, 10, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "private game create",
+			input: "Aug 28 08:30:10 [info ] game_create: game \"cows 3\" (pass \"moo\") type 0(normal) startver 0 created",
+			event: &Event{
+				Type:    EventGameCreate,
+				Game:    "cows 3",
+				Private: true,
+				Time:    time.Date(2020, 8, 28, 8, 30, 10, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "game join",
+			input: "Aug 28 08:30:11 [info ] game_add_player: [28] \"nokka\" joined game \"baal-12\"",
+			event: &Event{
+				Type:    EventGameJoin,
+				Account: "nokka",
+				Game:    "baal-12",
+				Time:    time.Date(2020, 8, 28, 8, 30, 11, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "game leave",
+			input: "Aug 28 08:45:02 [info ] game_del_player: [28] \"nokka\" left game \"baal-12\"",
+			event: &Event{
+				Type:    EventGameLeave,
+				Account: "nokka",
+				Game:    "baal-12",
+				Time:    time.Date(2020, 8, 28, 8, 45, 2, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "channel join",
+			input: "Aug 28 08:25:23 [info ] channel_add_connection: [28] \"nokka\" joined channel \"Diablo II\"",
+			event: &Event{
+				Type:    EventChannelJoin,
+				Account: "nokka",
+				Channel: "Diablo II",
+				Time:    time.Date(2020, 8, 28, 8, 25, 23, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "server start",
+			input: "Aug 28 07:00:01 [info ] main: PvPGN version 1.99.7.2.1 process 1234",
+			event: &Event{
+				Type: EventServerStart,
+				Time: time.Date(2020, 8, 28, 7, 0, 1, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "server shutdown",
+			input: "Aug 28 23:00:00 [info ] server_process: the server is shutting down (12 connections left)",
+			event: &Event{
+				Type: EventServerShutdown,
+				Time: time.Date(2020, 8, 28, 23, 0, 0, 0, time.UTC),
+			},
+			valid: true,
+		},
+		{
+			name:  "unrelated parse",
+			input: "Aug 28 09:01:48 [debug] sd_tcpinput: [28] read returned -1 (closing connection)",
+			event: nil,
+			valid: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, valid := decoder.DecodeEvent(tt.input)
+
+			if tt.valid != valid {
+				t.Fatalf("expected valid = %v; got = %v", tt.valid, valid)
+			}
+
+			if !reflect.DeepEqual(tt.event, event) {
+				t.Fatalf("expected: %v, got: %v", tt.event, event)
+			}
+		})
+	}
+}
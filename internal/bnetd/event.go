@@ -0,0 +1,103 @@
+package bnetd
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType is the kind of thing that happened on the server.
+type EventType string
+
+// Event types.
+const (
+	EventLogin          EventType = "login"
+	EventLogout         EventType = "logout"
+	EventAccountCreate  EventType = "account-create"
+	EventGameCreate     EventType = "game-create"
+	EventGameJoin       EventType = "game-join"
+	EventGameLeave      EventType = "game-leave"
+	EventChannelJoin    EventType = "channel-join"
+	EventServerStart    EventType = "server-start"
+	EventServerShutdown EventType = "server-shutdown"
+)
+
+// Event is something that happened on the server, decoded from a bnetd.log entry.
+type Event struct {
+	Type EventType
+
+	// Account the event is about, empty for server and game create events.
+	Account string
+
+	// Game is the name of the game for game events.
+	Game string
+
+	// Private is set when a created game is password protected.
+	Private bool
+
+	// Channel is the name of the channel for channel join events.
+	Channel string
+
+	Time time.Time
+
+	// Replayed is set for events written before the source started, such as
+	// entries replayed from the persisted position in bnetd.log.
+	Replayed bool
+}
+
+// StatusChange returns the status change of a login or logout event.
+func (e *Event) StatusChange() (*StatusChange, bool) {
+	switch e.Type {
+	case EventLogin, EventLogout:
+		return &StatusChange{
+			Account: e.Account,
+			Online:  e.Type == EventLogin,
+			Time:    e.Time,
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// statusEvent returns the login or logout event of a status change.
+func statusEvent(change *StatusChange) *Event {
+	t := EventLogout
+	if change.Online {
+		t = EventLogin
+	}
+
+	return &Event{
+		Type:    t,
+		Account: change.Account,
+		Time:    change.Time,
+	}
+}
+
+// Stream fans out server events to every subscribed handler.
+type Stream struct {
+	handlers []func(event *Event)
+	rwm      sync.RWMutex
+}
+
+// Subscribe registers a handler called with every published event.
+// Handlers are called in order on the publishing goroutine, so they must not block.
+func (s *Stream) Subscribe(handle func(event *Event)) {
+	s.rwm.Lock()
+	defer s.rwm.Unlock()
+
+	s.handlers = append(s.handlers, handle)
+}
+
+// Publish passes the event to every subscribed handler.
+func (s *Stream) Publish(event *Event) {
+	s.rwm.RLock()
+	defer s.rwm.RUnlock()
+
+	for _, handle := range s.handlers {
+		handle(event)
+	}
+}
+
+// NewStream returns an event stream without subscribers.
+func NewStream() *Stream {
+	return &Stream{}
+}
@@ -0,0 +1,209 @@
+package bnetd
+
+import (
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hpcloud/tail"
+)
+
+// How often the position in bnetd.log is persisted.
+const positionInterval = 5 * time.Second
+
+// FileSource is a source tailing the bnetd.log file.
+type FileSource struct {
+	filePath     string
+	positionPath string
+	position     position
+	positionLock sync.Mutex
+	tail         *tail.Tail
+	done         chan struct{}
+	decoder      decoder
+	// replayEnd is the size of the file on start, entries before it are replayed.
+	replayEnd int64
+}
+
+// Start will start listening for updates to the file. Entries written since the
+// last persisted position are replayed first, then the file is followed.
+func (s *FileSource) Start(handle func(event *Event)) error {
+	location, err := s.resume()
+	if err != nil {
+		return err
+	}
+
+	t, err := tail.TailFile(s.filePath, tail.Config{
+		Follow:   true,
+		ReOpen:   true,
+		Location: location,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.tail = t
+
+	// Receive lines written from the bnetd.log.
+	go func(t *tail.Tail) {
+		for line := range t.Lines {
+			offset := s.advance(int64(len(line.Text)) + 1)
+
+			if event, valid := s.decoder.DecodeEvent(line.Text); valid {
+				event.Replayed = offset < s.replayEnd
+				handle(event)
+			}
+		}
+	}(t)
+
+	if s.positionPath != "" {
+		go s.persist()
+	}
+
+	return nil
+}
+
+// Stop stops following the file and persists the current position.
+func (s *FileSource) Stop() error {
+	if s.tail == nil {
+		return nil
+	}
+
+	close(s.done)
+
+	err := s.tail.Stop()
+	if err != nil {
+		return err
+	}
+
+	return s.savePosition()
+}
+
+// OnlineAccounts scans the whole file to work out who is online.
+func (s *FileSource) OnlineAccounts() ([]string, error) {
+	return NewLogScanner(s.filePath).OnlineAccounts()
+}
+
+// resume works out where to start reading the file. Without a persisted
+// position it starts at the end, otherwise it continues from the position,
+// or from the start if the file has been rotated since or doesn't exist yet.
+func (s *FileSource) resume() (*tail.SeekInfo, error) {
+	end := &tail.SeekInfo{Offset: 0, Whence: io.SeekEnd}
+
+	if s.positionPath == "" {
+		return end, nil
+	}
+
+	fi, err := os.Stat(s.filePath)
+	if os.IsNotExist(err) {
+		// The file is followed once it's created, everything in it is new.
+		log.Println("bnetd.log doesn't exist yet, reading it from the start once it's created")
+		return &tail.SeekInfo{Offset: 0, Whence: io.SeekStart}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.position = position{
+		Offset: fi.Size(),
+		Inode:  inode(fi),
+		Size:   fi.Size(),
+	}
+
+	// Everything in the file up until now was written before the start.
+	s.replayEnd = fi.Size()
+
+	saved, err := loadPosition(s.positionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// First run, there is nothing to replay.
+	if saved == nil {
+		return end, nil
+	}
+
+	// The file was replaced or truncated, replay all of it.
+	if saved.Inode != s.position.Inode || fi.Size() < saved.Offset {
+		log.Println("bnetd.log rotated since last run, replaying from the start")
+		s.position.Offset = 0
+		return &tail.SeekInfo{Offset: 0, Whence: io.SeekStart}, nil
+	}
+
+	log.Printf("replaying bnetd.log from offset %d", saved.Offset)
+	s.position.Offset = saved.Offset
+
+	return &tail.SeekInfo{Offset: saved.Offset, Whence: io.SeekStart}, nil
+}
+
+// advance moves the position forward by the number of bytes handled,
+// returning the offset the bytes started at.
+func (s *FileSource) advance(n int64) int64 {
+	s.positionLock.Lock()
+	defer s.positionLock.Unlock()
+
+	offset := s.position.Offset
+	s.position.Offset += n
+
+	return offset
+}
+
+// persist saves the position periodically until the source is stopped.
+func (s *FileSource) persist() {
+	ticker := time.NewTicker(positionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.savePosition(); err != nil {
+				log.Println("failed to save bnetd.log position", err)
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// savePosition persists the current position along with the inode and size of the file.
+func (s *FileSource) savePosition() error {
+	if s.positionPath == "" {
+		return nil
+	}
+
+	fi, err := os.Stat(s.filePath)
+	if os.IsNotExist(err) {
+		// Nothing has been read yet.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.positionLock.Lock()
+	defer s.positionLock.Unlock()
+
+	// The file was rotated and is being followed from the start, restart
+	// counting. It might count too few bytes, which replays a few entries
+	// on restart rather than missing them.
+	if ino := inode(fi); ino != s.position.Inode {
+		s.position.Inode = ino
+		s.position.Offset = 0
+	}
+
+	s.position.Size = fi.Size()
+
+	return savePosition(s.positionPath, s.position)
+}
+
+// NewFileSource returns a source tailing the bnetd.log at filePath. The position
+// in the file is persisted at positionPath, or not at all if it's empty.
+func NewFileSource(filePath string, positionPath string) *FileSource {
+	return &FileSource{
+		filePath:     filePath,
+		positionPath: positionPath,
+		done:         make(chan struct{}),
+		decoder:      decoder{},
+	}
+}
@@ -0,0 +1,124 @@
+package bnetd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileSourceReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "bnetd.log")
+	positionPath := filepath.Join(dir, "bnetd.position")
+
+	handled := "Aug 28 08:25:22 [info ] _client_loginreq2: [28] \"nokka\" logged in (correct password)\n"
+	missed := "Aug 28 08:26:22 [info ] _client_loginreq2: [29] \"meanski\" logged in (correct password)\n"
+	live := "Aug 28 08:27:22 [info ] _client_loginreq2: [30] \"bruse\" logged in (correct password)\n"
+
+	err = ioutil.WriteFile(logPath, []byte(handled+missed), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first entry was handled before the restart.
+	err = savePosition(positionPath, position{Offset: int64(len(handled)), Inode: inode(fi), Size: fi.Size()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan *Event, 3)
+
+	s := NewFileSource(logPath, positionPath)
+
+	err = s.Start(func(event *Event) {
+		events <- event
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Stop()
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = f.WriteString(live)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		account  string
+		replayed bool
+	}{
+		{account: "meanski", replayed: true},
+		{account: "bruse", replayed: false},
+	}
+
+	for _, w := range want {
+		select {
+		case event := <-events:
+			if event.Account != w.account || event.Replayed != w.replayed {
+				t.Fatalf("expected: %s replayed %v, got: %s replayed %v", w.account, w.replayed, event.Account, event.Replayed)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", w.account)
+		}
+	}
+}
+
+func TestFileSourceMissingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	logPath := filepath.Join(dir, "bnetd.log")
+	positionPath := filepath.Join(dir, "bnetd.position")
+
+	events := make(chan *Event, 1)
+
+	s := NewFileSource(logPath, positionPath)
+
+	err = s.Start(func(event *Event) {
+		events <- event
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer s.Stop()
+
+	// The file is created after the start, like a server started after the bot.
+	err = ioutil.WriteFile(logPath, []byte("Aug 28 08:25:22 [info ] _client_loginreq2: [28] \"nokka\" logged in (correct password)\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Account != "nokka" || event.Replayed {
+			t.Fatalf("expected: nokka not replayed, got: %s replayed %v", event.Account, event.Replayed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for nokka")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package bnetd
+
+import (
+	"os"
+	"syscall"
+)
+
+// inode returns the inode of the file, used to detect log rotation.
+func inode(fi os.FileInfo) uint64 {
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Ino)
+	}
+
+	return 0
+}
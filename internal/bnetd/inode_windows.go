@@ -0,0 +1,8 @@
+package bnetd
+
+import "os"
+
+// inode isn't available on windows, rotation is only detected by the file shrinking.
+func inode(fi os.FileInfo) uint64 {
+	return 0
+}
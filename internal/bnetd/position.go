@@ -0,0 +1,65 @@
+package bnetd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// position is how far into bnetd.log the watcher has handled entries, along
+// with the inode and size of the file to detect if it has been rotated.
+type position struct {
+	Offset int64  `json:"offset"`
+	Inode  uint64 `json:"inode"`
+	Size   int64  `json:"size"`
+}
+
+// loadPosition reads a persisted position, it returns nil if there is none.
+func loadPosition(path string) (*position, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var p position
+
+	err = json.Unmarshal(data, &p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// savePosition persists the position, writing to a temporary file first
+// so a crash never leaves a half written position behind.
+func savePosition(path string, p position) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
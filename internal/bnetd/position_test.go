@@ -0,0 +1,45 @@
+package bnetd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPosition(t *testing.T) {
+	dir, err := ioutil.TempDir("", "position")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "bnetd.position")
+
+	p, err := loadPosition(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p != nil {
+		t.Fatalf("expected no position, got: %v", p)
+	}
+
+	want := position{Offset: 1024, Inode: 42, Size: 2048}
+
+	err = savePosition(path, want)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p, err = loadPosition(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(&want, p) {
+		t.Fatalf("expected: %v, got: %v", want, p)
+	}
+}
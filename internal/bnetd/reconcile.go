@@ -0,0 +1,99 @@
+package bnetd
+
+import (
+	"bufio"
+	"os"
+	"sort"
+)
+
+// PresenceSource knows which accounts are currently online on the server.
+type PresenceSource interface {
+	OnlineAccounts() ([]string, error)
+}
+
+// LogScanner is a presence source scanning bnetd.log, replaying every login
+// and logout since the server last started to work out who is online.
+type LogScanner struct {
+	filePath string
+	decoder  decoder
+}
+
+// OnlineAccounts scans the log and returns the accounts online at the end of it.
+// If the log doesn't contain a server start all of it is replayed, if it doesn't exist nobody is online.
+func (s *LogScanner) OnlineAccounts() ([]string, error) {
+	f, err := os.Open(s.filePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	online := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Nobody is online when the server has just started.
+		if s.decoder.ServerStarted(line) {
+			online = make(map[string]struct{})
+			continue
+		}
+
+		change, valid := s.decoder.Decode(line)
+		if !valid {
+			continue
+		}
+
+		if change.Online {
+			online[change.Account] = struct{}{}
+		} else {
+			delete(online, change.Account)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	accounts := make([]string, 0, len(online))
+	for account := range online {
+		accounts = append(accounts, account)
+	}
+
+	sort.Strings(accounts)
+
+	return accounts, nil
+}
+
+// NewLogScanner returns a presence source scanning the bnetd.log at the given path.
+func NewLogScanner(filePath string) *LogScanner {
+	return &LogScanner{
+		filePath: filePath,
+		decoder:  decoder{},
+	}
+}
+
+// Reconcile corrects the online state of all subscribers in both the
+// persistent and in memory store with the accounts online according
+// to the source. It's run on start up before accepting any traffic.
+func Reconcile(source PresenceSource, inmem subscriberRepository, subscribers subscriberRepository) error {
+	accounts, err := source.OnlineAccounts()
+	if err != nil {
+		return err
+	}
+
+	// Update persistent store first.
+	err = subscribers.SyncOnlineStatus(accounts)
+	if err != nil {
+		return err
+	}
+
+	// Update persisted, update the inmem store.
+	return inmem.SyncOnlineStatus(accounts)
+}
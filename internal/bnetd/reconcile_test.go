@@ -0,0 +1,68 @@
+package bnetd
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLogScannerOnlineAccounts(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		accounts []string
+	}{
+		{
+			name: "logins and logouts",
+			lines: []string{
+				"Aug 28 08:25:22 [info ] _client_loginreq2: [28] \"nokka\" logged in (correct password)",
+				"Aug 28 08:26:22 [info ] _client_loginreq2: [29] \"meanski\" logged in (correct password)",
+				"Aug 28 08:27:22 [info ] conn_destroy: [29] \"meanski\" logged out",
+				"Aug 28 08:28:22 [info ] handle_telnet_packet: [30] \"chat\" bot logged in (correct password)",
+			},
+			accounts: []string{"chat", "nokka"},
+		},
+		{
+			name: "server restart",
+			lines: []string{
+				"Aug 28 08:25:22 [info ] _client_loginreq2: [28] \"nokka\" logged in (correct password)",
+				"Aug 28 09:00:00 [info ] main: PvPGN version 1.99.7.2.1 process 1234",
+				"Aug 28 09:01:22 [info ] _client_loginreq2: [5] \"meanski\" logged in (correct password)",
+			},
+			accounts: []string{"meanski"},
+		},
+		{
+			name:     "empty log",
+			lines:    nil,
+			accounts: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "bnetd.log")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer os.Remove(f.Name())
+
+			_, err = f.WriteString(strings.Join(tt.lines, "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			accounts, err := NewLogScanner(f.Name()).OnlineAccounts()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(tt.accounts, accounts) {
+				t.Fatalf("expected: %v, got: %v", tt.accounts, accounts)
+			}
+		})
+	}
+}
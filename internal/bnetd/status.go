@@ -0,0 +1,144 @@
+package bnetd
+
+import (
+	"bufio"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// User entry in the [USERS] section of the PvpGN status dump, such as "user1=D2XP,nokka".
+var userRegex = regexp.MustCompile(`(?i)^user\d+=[^,]*,([a-z0-9_\-]+)`)
+
+// StatusSource is a source polling the status dump PvpGN writes
+// periodically to status_dir, usually status/server.dat.
+type StatusSource struct {
+	filePath string
+	interval time.Duration
+	online   map[string]struct{}
+	done     chan struct{}
+}
+
+// Start starts polling the status dump, producing a login or logout event
+// for every account that logged in or out since the previous poll.
+func (s *StatusSource) Start(handle func(event *Event)) error {
+	accounts, err := s.OnlineAccounts()
+	if err != nil {
+		return err
+	}
+
+	s.online = toSet(accounts)
+
+	go s.poll(handle)
+
+	return nil
+}
+
+// Stop stops polling.
+func (s *StatusSource) Stop() error {
+	close(s.done)
+	return nil
+}
+
+// OnlineAccounts reads the accounts currently online from the status dump.
+func (s *StatusSource) OnlineAccounts() ([]string, error) {
+	f, err := os.Open(s.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	return parseStatus(f)
+}
+
+func (s *StatusSource) poll(handle func(event *Event)) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			accounts, err := s.OnlineAccounts()
+			if err != nil {
+				log.Println("failed to read status dump", err)
+				continue
+			}
+
+			now := time.Now()
+			online := toSet(accounts)
+
+			for account := range online {
+				if _, ok := s.online[account]; !ok {
+					handle(&Event{Type: EventLogin, Account: account, Time: now})
+				}
+			}
+
+			for account := range s.online {
+				if _, ok := online[account]; !ok {
+					handle(&Event{Type: EventLogout, Account: account, Time: now})
+				}
+			}
+
+			s.online = online
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// parseStatus reads the accounts listed in the [USERS] section of a status dump.
+func parseStatus(r io.Reader) ([]string, error) {
+	var (
+		users    bool
+		accounts = make([]string, 0)
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[") {
+			users = strings.EqualFold(line, "[USERS]")
+			continue
+		}
+
+		if !users {
+			continue
+		}
+
+		if matches := userRegex.FindStringSubmatch(line); len(matches) == 2 {
+			accounts = append(accounts, strings.ToLower(matches[1]))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(accounts)
+
+	return accounts, nil
+}
+
+func toSet(accounts []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(accounts))
+	for _, account := range accounts {
+		set[account] = struct{}{}
+	}
+
+	return set
+}
+
+// NewStatusSource returns a source polling the status dump at filePath every interval.
+func NewStatusSource(filePath string, interval time.Duration) *StatusSource {
+	return &StatusSource{
+		filePath: filePath,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
@@ -0,0 +1,54 @@
+package bnetd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		accounts []string
+	}{
+		{
+			name: "users online",
+			input: `[STATUS]
+Version=1.99.7.2.1
+Uptime=0 hours 5 minutes 12 seconds
+Games=1
+Users=2
+[CHANNELS]
+channel1=Diablo II-1
+[GAMES]
+game1=D2XP,baal-12
+[USERS]
+user1=D2XP,Nokka
+user2=CHAT,chat
+`,
+			accounts: []string{"chat", "nokka"},
+		},
+		{
+			name: "no users",
+			input: `[STATUS]
+Users=0
+[USERS]
+`,
+			accounts: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts, err := parseStatus(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(tt.accounts, accounts) {
+				t.Fatalf("expected: %v, got: %v", tt.accounts, accounts)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package bnetd
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Priority prefix of syslog messages, such as "<30>".
+var priorityRegex = regexp.MustCompile(`^<\d{1,3}>`)
+
+// Max size of a syslog message received over udp.
+const maxPacketSize = 64 * 1024
+
+// SyslogSource is a source listening for bnetd log entries sent by syslog
+// over udp, or over tcp with one message per line.
+type SyslogSource struct {
+	network  string
+	address  string
+	decoder  decoder
+	packet   net.PacketConn
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	connLock sync.Mutex
+}
+
+// Start starts listening for syslog messages.
+func (s *SyslogSource) Start(handle func(event *Event)) error {
+	switch s.network {
+	case "udp", "udp4", "udp6":
+		pc, err := net.ListenPacket(s.network, s.address)
+		if err != nil {
+			return err
+		}
+
+		s.packet = pc
+
+		go s.readPackets(handle)
+	case "tcp", "tcp4", "tcp6":
+		l, err := net.Listen(s.network, s.address)
+		if err != nil {
+			return err
+		}
+
+		s.listener = l
+
+		go s.accept(handle)
+	default:
+		return fmt.Errorf("unsupported syslog network %q", s.network)
+	}
+
+	return nil
+}
+
+// Stop stops listening and closes all open connections.
+func (s *SyslogSource) Stop() error {
+	if s.packet != nil {
+		return s.packet.Close()
+	}
+
+	if s.listener != nil {
+		s.connLock.Lock()
+		for conn := range s.conns {
+			conn.Close()
+		}
+		s.connLock.Unlock()
+
+		return s.listener.Close()
+	}
+
+	return nil
+}
+
+// readPackets reads udp packets until the connection is closed, a packet can hold several messages.
+func (s *SyslogSource) readPackets(handle func(event *Event)) {
+	buf := make([]byte, maxPacketSize)
+
+	for {
+		n, _, err := s.packet.ReadFrom(buf)
+		if err != nil {
+			log.Println("stopped reading syslog packets", err)
+			return
+		}
+
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			s.handleLine(line, handle)
+		}
+	}
+}
+
+// accept accepts tcp connections until the listener is closed.
+func (s *SyslogSource) accept(handle func(event *Event)) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			log.Println("stopped accepting syslog connections", err)
+			return
+		}
+
+		s.connLock.Lock()
+		s.conns[conn] = struct{}{}
+		s.connLock.Unlock()
+
+		go s.read(conn, handle)
+	}
+}
+
+// read reads newline separated messages from the connection until it's closed.
+func (s *SyslogSource) read(conn net.Conn, handle func(event *Event)) {
+	defer func() {
+		s.connLock.Lock()
+		delete(s.conns, conn)
+		s.connLock.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		s.handleLine(scanner.Text(), handle)
+	}
+}
+
+// handleLine strips the syslog priority and decodes the bnetd entry.
+func (s *SyslogSource) handleLine(line string, handle func(event *Event)) {
+	line = priorityRegex.ReplaceAllString(strings.TrimSpace(line), "")
+
+	if event, valid := s.decoder.DecodeEvent(line); valid {
+		handle(event)
+	}
+}
+
+// NewSyslogSource returns a source listening for syslog messages on the network and address.
+func NewSyslogSource(network string, address string) *SyslogSource {
+	return &SyslogSource{
+		network: network,
+		address: address,
+		decoder: decoder{},
+		conns:   make(map[net.Conn]struct{}),
+	}
+}
@@ -0,0 +1,59 @@
+package bnetd
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSyslogSource(t *testing.T) {
+	lines := "<30>Aug 28 08:25:22 [info ] _client_loginreq2: [28] \"nokka\" logged in (correct password)\n" +
+		"<30>Aug 28 08:26:22 [info ] conn_destroy: [28] \"nokka\" logged out\n"
+
+	for _, network := range []string{"udp", "tcp"} {
+		t.Run(network, func(t *testing.T) {
+			events := make(chan *Event, 2)
+
+			s := NewSyslogSource(network, "127.0.0.1:0")
+
+			err := s.Start(func(event *Event) {
+				events <- event
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer s.Stop()
+
+			var addr net.Addr
+			if s.packet != nil {
+				addr = s.packet.LocalAddr()
+			} else {
+				addr = s.listener.Addr()
+			}
+
+			conn, err := net.Dial(network, addr.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer conn.Close()
+
+			_, err = conn.Write([]byte(lines))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range []EventType{EventLogin, EventLogout} {
+				select {
+				case event := <-events:
+					if event.Type != want || event.Account != "nokka" {
+						t.Fatalf("expected: %s by nokka, got: %s by %s", want, event.Type, event.Account)
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatalf("timed out waiting for %s", want)
+				}
+			}
+		})
+	}
+}
@@ -1,14 +1,20 @@
 package bnetd
 
 import (
-	"io"
-
-	"github.com/hpcloud/tail"
+	"log"
+	"time"
 )
 
 // subscriberRepository is the interface representation of the data layer.
 type subscriberRepository interface {
 	UpdateOnlineStatus(account string, online bool) error
+	SyncOnlineStatus(online []string) error
+}
+
+// presenceRepository is the interface representation of the login history data layer.
+type presenceRepository interface {
+	UpdateLastLogin(account string, t time.Time) error
+	UpdateLastLogout(account string, t time.Time) error
 }
 
 // inmemRepository is the interface representation of the in mem data layer.
@@ -17,68 +23,90 @@ type inmemRepository interface {
 	SubscriberExists(account string) bool
 }
 
-// Watcher will listen for updates on the bnetd.log file to update subscriber online state.
+// Source produces the events happening on the server, such as accounts logging in and out.
+type Source interface {
+	// Start starts producing events, calling handle for each of them.
+	Start(handle func(event *Event)) error
+
+	// Stop stops producing events.
+	Stop() error
+}
+
+// Watcher will listen for events from a source to update subscriber online state,
+// and publishes every event on the event stream for other features to consume.
 type Watcher struct {
-	filePath    string
-	decoder     decoder
+	source      Source
 	inmem       inmemRepository
 	subscribers subscriberRepository
+	presence    presenceRepository
+	events      *Stream
 }
 
-// Start will start listening for updates to the file.
+// Start will start listening for events from the source.
 func (w *Watcher) Start() error {
-	t, err := tail.TailFile(w.filePath, tail.Config{
-		Follow: true,
-		ReOpen: true,
-		Location: &tail.SeekInfo{
-			Offset: 0,
-			Whence: io.SeekEnd,
-		},
-	})
-	if err != nil {
-		return err
-	}
+	return w.source.Start(w.HandleEvent)
+}
 
-	// Receive lines written from the bnetd.log.
-	go func(t *tail.Tail) {
-		for line := range t.Lines {
-			w.HandleUpdate(line.Text)
+// HandleEvent updates the online state on logins and logouts, then publishes the event.
+// Replayed events only update the online state, they already happened.
+func (w *Watcher) HandleEvent(event *Event) {
+	if change, ok := event.StatusChange(); ok {
+		if err := w.HandleUpdate(change); err != nil {
+			log.Println("failed to handle status change", err)
 		}
-	}(t)
+	}
 
-	return nil
+	if event.Replayed {
+		return
+	}
+
+	w.events.Publish(event)
 }
 
-// HandleUpdate decodes login/logout entries on the bnetd.log and decides if
-// the users online state has to be changed.
-func (w *Watcher) HandleUpdate(data string) error {
-	change, valid := w.decoder.Decode(data)
+// Stop stops the source.
+func (w *Watcher) Stop() error {
+	return w.source.Stop()
+}
 
-	if valid {
-		exists := w.inmem.SubscriberExists(change.Account)
+// HandleUpdate records the login or logout and decides if the users online state has to be changed.
+func (w *Watcher) HandleUpdate(change *StatusChange) error {
+	// Keep track of when every account logs in and out.
+	var err error
+	if change.Online {
+		err = w.presence.UpdateLastLogin(change.Account, change.Time)
+	} else {
+		err = w.presence.UpdateLastLogout(change.Account, change.Time)
+	}
 
-		// Subscriber exists so it needs to be updated.
-		if exists {
-			// Update persistent store with the new online state.
-			err := w.subscribers.UpdateOnlineStatus(change.Account, change.Online)
-			if err != nil {
-				return err
-			}
+	// Login history is nice to have, the online state is what delivery relies on.
+	if err != nil {
+		log.Println("failed to update presence", err)
+	}
 
-			// Update persisted, update the inmem store.
-			w.inmem.UpdateOnlineStatus(change.Account, change.Online)
+	exists := w.inmem.SubscriberExists(change.Account)
+
+	// Subscriber exists so it needs to be updated.
+	if exists {
+		// Update persistent store with the new online state.
+		err := w.subscribers.UpdateOnlineStatus(change.Account, change.Online)
+		if err != nil {
+			return err
 		}
+
+		// Update persisted, update the inmem store.
+		w.inmem.UpdateOnlineStatus(change.Account, change.Online)
 	}
 
 	return nil
 }
 
-// NewWatcher returns a new bnetd watcher with all the dependencies.
-func NewWatcher(filePath string, inmem inmemRepository, subscribers subscriberRepository) *Watcher {
+// NewWatcher returns a new watcher with all the dependencies.
+func NewWatcher(source Source, inmem inmemRepository, subscribers subscriberRepository, presence presenceRepository, events *Stream) *Watcher {
 	return &Watcher{
-		decoder:     decoder{},
-		filePath:    filePath,
+		source:      source,
 		inmem:       inmem,
 		subscribers: subscribers,
+		presence:    presence,
+		events:      events,
 	}
 }
@@ -0,0 +1,99 @@
+package bnetd
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeRepository records online status and presence updates in memory.
+type fakeRepository struct {
+	online      map[string]bool
+	logins      map[string]time.Time
+	presenceErr error
+}
+
+func (r *fakeRepository) UpdateOnlineStatus(account string, online bool) error {
+	r.online[account] = online
+	return nil
+}
+
+func (r *fakeRepository) SyncOnlineStatus(online []string) error {
+	return nil
+}
+
+func (r *fakeRepository) SubscriberExists(account string) bool {
+	return true
+}
+
+func (r *fakeRepository) UpdateLastLogin(account string, t time.Time) error {
+	if r.presenceErr != nil {
+		return r.presenceErr
+	}
+
+	r.logins[account] = t
+	return nil
+}
+
+func (r *fakeRepository) UpdateLastLogout(account string, t time.Time) error {
+	return r.presenceErr
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		online: make(map[string]bool),
+		logins: make(map[string]time.Time),
+	}
+}
+
+func TestWatcherHandleEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		event     Event
+		published bool
+	}{
+		{name: "live login", event: Event{Type: EventLogin, Account: "nokka"}, published: true},
+		{name: "replayed login", event: Event{Type: EventLogin, Account: "nokka", Replayed: true}, published: false},
+		{name: "replayed game", event: Event{Type: EventGameCreate, Game: "baal-1", Replayed: true}, published: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeRepository()
+			events := NewStream()
+
+			var published bool
+			events.Subscribe(func(event *Event) {
+				published = true
+			})
+
+			w := NewWatcher(nil, repo, repo, repo, events)
+			w.HandleEvent(&tt.event)
+
+			if published != tt.published {
+				t.Fatalf("expected published: %v, got: %v", tt.published, published)
+			}
+
+			// Replayed logins still update the online state.
+			if tt.event.Type == EventLogin && !repo.online[tt.event.Account] {
+				t.Fatalf("expected %s to be online", tt.event.Account)
+			}
+		})
+	}
+}
+
+func TestWatcherHandleUpdatePresenceFailure(t *testing.T) {
+	repo := newFakeRepository()
+	repo.presenceErr = errors.New("presence unavailable")
+
+	w := NewWatcher(nil, repo, repo, repo, NewStream())
+
+	err := w.HandleUpdate(&StatusChange{Account: "nokka", Online: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !repo.online["nokka"] {
+		t.Fatal("expected nokka to be online despite the presence error")
+	}
+}
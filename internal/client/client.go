@@ -1,25 +1,143 @@
 package client
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"log"
-	"strconv"
+	"math/rand"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/nokka/d2-chatbot/internal/game"
+	"github.com/nokka/d2-chatbot/internal/history"
+	"github.com/nokka/d2-chatbot/internal/moderation"
+	"github.com/nokka/d2-chatbot/internal/presence"
+	"github.com/nokka/d2-chatbot/internal/role"
 	"github.com/nokka/d2-chatbot/internal/subscriber"
+	"github.com/nokka/d2-chatbot/internal/trade"
 	"github.com/nokka/d2client"
 )
 
+// Backoff boundaries used when reconnecting to the d2 server.
+const (
+	minBackoff = 1 * time.Second
+	maxBackoff = 2 * time.Minute
+)
+
+// Number of moderation actions whispered when looking up an account.
+const modLogLimit = 5
+
+// ErrNotConnected is returned when writing while the client has no open connection.
+var ErrNotConnected = errors.New("client is not connected")
+
+// State is the state of the connection to the d2 server.
+type State int
+
+// Connection states.
+const (
+	StateDisconnected State = iota
+	StateConnecting
+	StateConnected
+)
+
+// String returns a readable representation of the state.
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	default:
+		return "disconnected"
+	}
+}
+
+// Options holds the tunable settings of a client.
+type Options struct {
+	// WhisperRate is the number of whispers sent per second.
+	WhisperRate float64
+
+	// WhisperBurst is the number of whispers that can be sent at once before throttling.
+	WhisperBurst int
+
+	// WhisperQueueSize is the max number of whispers waiting to be sent, whispers are dropped beyond it.
+	WhisperQueueSize int
+
+	// RateLimit is the number of messages an account can publish per RateWindow, 0 disables rate limiting.
+	RateLimit  int
+	RateWindow time.Duration
+
+	// SlowMode is the interval between messages used when a moderator enables slow mode, 0 disables slow mode.
+	SlowMode time.Duration
+
+	// LFGExpiry is how long looking for group posts stay on the board.
+	LFGExpiry time.Duration
+
+	// TradeListings enables storing trade posts as listings that can be searched for ListingExpiry.
+	TradeListings bool
+	ListingExpiry time.Duration
+
+	// HistorySize is the number of latest messages kept in memory, HistoryRetention how long they're kept.
+	HistorySize      int
+	HistoryRetention time.Duration
+
+	// Bots are the accounts of every channel bot, they're never hinted to so bots can't whisper each other forever.
+	Bots []string
+}
+
 // subscriberRepository is the interface representation of the data layer.
 type subscriberRepository interface {
 	FindSubscribers(chatID string) ([]subscriber.Subscriber, error)
 	FindEligibleSubscribers(chatID string) ([]subscriber.Subscriber, error)
 	Subscribe(account string, chatID string) error
 	Unsubscribe(account string, chatID string) error
-	UpdateBannedUntil(account string, chatID string, until *time.Time) error
-	FindModerators() ([]string, error)
+	UpdateBan(account string, chatID string, until *time.Time, permanent bool) error
+	UpdateOnlineStatus(account string, online bool) error
+	UpdateDigest(account string, chatID string, enabled bool) error
+	UpdateMentions(account string, chatID string, enabled bool) error
+	AddRole(r role.Role) error
+	RemoveRole(r role.Role) error
+	AddGameFilter(f game.Filter) error
+	RemoveGameFilters(account string, chatID string, pattern string) error
+	AddIgnore(ig subscriber.Ignore) error
+	RemoveIgnore(ig subscriber.Ignore) error
+}
+
+// moderationRepository is the interface representation of the moderation audit log.
+type moderationRepository interface {
+	LogAction(action moderation.Action) error
+	FindActions(target string, chatID string, limit int) ([]moderation.Action, error)
+}
+
+// presenceRepository is the interface representation of the login history data layer.
+type presenceRepository interface {
+	FindPresence(account string) (*presence.Presence, error)
+}
+
+// tradeRepository is the interface representation of the trade listings and watches data layer.
+type tradeRepository interface {
+	AddListing(l trade.Listing) (int64, bool, error)
+	FindListings(chatID string, terms []string, now time.Time, limit int) ([]trade.Listing, error)
+	FindAccountListings(account string, chatID string, now time.Time) ([]trade.Listing, error)
+	RemoveListing(id int64, account string) (bool, error)
+	RemoveExpiredListings(now time.Time) (int64, error)
+	FindWatches(chatID string) ([]trade.Watch, error)
+	FindAccountWatches(account string, chatID string) ([]trade.Watch, error)
+	AddWatch(w trade.Watch) error
+	RemoveWatches(account string, chatID string, keyword string) (int64, error)
+}
+
+// historyRepository is the interface representation of the message history and queued mentions data layer.
+type historyRepository interface {
+	AddMessage(m history.Message) error
+	FindMessages(chatID string, since time.Time, limit int) ([]history.Message, error)
+	RemoveMessages(chatID string, before time.Time) (int64, error)
+	AddMention(m history.Mention) error
+	FindMentions(account string, chatID string, limit int) ([]history.Mention, error)
+	RemoveMentions(account string, chatID string) error
+	RemoveOldMentions(chatID string, before time.Time) (int64, error)
 }
 
 // inmemRepository is the interface representation of the in mem data layer.
@@ -27,46 +145,92 @@ type inmemRepository interface {
 	subscriberRepository
 	SyncSubscribers(chatID string, subscribers []subscriber.Subscriber) error
 	FindSubscriber(account string, chatID string) *subscriber.Subscriber
+	FindAccountRoles(account string) []role.Role
+	FindGameFilters(chatID string) []game.Filter
+	FindIgnores(account string) []subscriber.Ignore
+	IsIgnoring(account string, sender string, chatID string) bool
 }
 
 // Client wraps the connection to the d2 server and is responsible for communication.
 type Client struct {
-	addr        string
-	chatID      string
-	password    string
-	decoder     decoder
-	conn        d2client.Client
-	inmem       inmemRepository
-	subscribers subscriberRepository
-	publishLock sync.Mutex
+	addr             string
+	chatID           string
+	account          string
+	password         string
+	decoder          decoder
+	conn             d2client.Client
+	state            State
+	reconnects       int
+	connLock         sync.RWMutex
+	queue            *queue
+	deliveries       *deliveries
+	games            *createdGames
+	lfg              *lfgBoard
+	lfgExpiry        time.Duration
+	tradeListings    bool
+	listingExpiry    time.Duration
+	history          *ring
+	historyRetention time.Duration
+	limiter          *limiter
+	helps            *limiter
+	hints            *limiter
+	bots             map[string]bool
+	slowMode         time.Duration
+	inmem            inmemRepository
+	subscribers      subscriberRepository
+	moderation       moderationRepository
+	presence         presenceRepository
+	trades           tradeRepository
+	messages         historyRepository
+	publishLock      sync.Mutex
 }
 
 // Open will open a tcp connection to the d2 server.
 func (c *Client) Open() error {
-	// Create a new d2 tcp client.
-	client := d2client.New()
-
-	// Open connection over tcp.
-	err := client.Open(c.addr)
+	err := c.connect()
 	if err != nil {
 		return err
 	}
 
-	// Login with the username and password.
-	err = client.Login(c.chatID, c.password)
-	if err != nil {
-		return err
-	}
+	// Send queued whispers.
+	go c.queue.run()
 
-	// Add the tcp connection to our client.
-	c.conn = client
+	// Listen for data on the connection indefinitely, reconnecting when it drops.
+	go c.run()
 
-	// Listen for data on the connection indefinitely.
-	go c.listenAndClose()
+	// Remove looking for group posts as they expire.
+	go c.expireLFG()
+
+	// Remove messages past retention.
+	go c.expireHistory()
+
+	// Remove trade listings as they expire.
+	if c.tradeListings {
+		go c.expireListings()
+	}
 
 	return nil
 }
 
+// State returns the current state of the connection.
+func (c *Client) State() State {
+	c.connLock.RLock()
+	defer c.connLock.RUnlock()
+	return c.state
+}
+
+// ReconnectAttempts returns the number of reconnect attempts made since the client was opened.
+func (c *Client) ReconnectAttempts() int {
+	c.connLock.RLock()
+	defer c.connLock.RUnlock()
+	return c.reconnects
+}
+
+// QueueStats returns the counters of the outbound whisper queue.
+func (c *Client) QueueStats() QueueStats {
+	return c.queue.Stats()
+}
+
 // Sync will sync subscribers from persistent storage to in memory storage.
 // It's usually run once on start up to get the current state.
 func (c *Client) Sync() error {
@@ -80,7 +244,7 @@ func (c *Client) Sync() error {
 		return err
 	}
 
-	return nil
+	return c.syncHistory()
 }
 
 // Subscribe receives a message and subscribes the calling user.
@@ -108,13 +272,13 @@ func (c *Client) Subscribe(message *Message) error {
 		}
 
 		// Notify subscriber that they have been successfully subscribed.
-		c.conn.Whisper(message.Account, fmt.Sprintf("[subscribed %s]", c.chatID))
+		c.whisper(message.Account, fmt.Sprintf("[subscribed %s]", c.chatID))
 
 		return nil
 	}
 
 	// Notify subscriber that they are already subscribed.
-	c.conn.Whisper(message.Account, fmt.Sprintf("[already subscribed to %s] ", c.chatID))
+	c.whisper(message.Account, fmt.Sprintf("[already subscribed to %s] ", c.chatID))
 
 	return nil
 }
@@ -124,7 +288,7 @@ func (c *Client) Unsubscribe(message *Message) error {
 	// Check in memory store if the account is subscribed.
 	sub := c.inmem.FindSubscriber(message.Account, c.chatID)
 	if sub == nil {
-		c.conn.Whisper(message.Account, fmt.Sprintf("[not subscribed to %s]", c.chatID))
+		c.whisper(message.Account, fmt.Sprintf("[not subscribed to %s]", c.chatID))
 		return nil
 	}
 
@@ -146,7 +310,7 @@ func (c *Client) Unsubscribe(message *Message) error {
 	}
 
 	// Notify subscriber.
-	c.conn.Whisper(message.Account, fmt.Sprintf("[unsubscribed %s]", c.chatID))
+	c.whisper(message.Account, fmt.Sprintf("[unsubscribed %s]", c.chatID))
 
 	return nil
 }
@@ -162,7 +326,7 @@ func (c *Client) Publish(message *Message) error {
 	// Check in memory store if the account is subscribed to the chat.
 	sub := c.inmem.FindSubscriber(message.Account, c.chatID)
 	if sub == nil {
-		c.conn.Whisper(message.Account, fmt.Sprintf("[not subscribed to %s]", c.chatID))
+		c.whisper(message.Account, fmt.Sprintf("[not subscribed to %s]", c.chatID))
 		return nil
 	}
 
@@ -171,6 +335,48 @@ func (c *Client) Publish(message *Message) error {
 		return nil
 	}
 
+	// Moderators aren't rate limited, everyone else has to keep within the limits.
+	if !c.allowed(message.Account, role.PermissionBypassLimits) {
+		if wait, ok := c.limiter.Allow(message.Account, time.Now()); !ok {
+			c.whisper(message.Account, fmt.Sprintf("[slow down, you can post on %s again in %v]", c.chatID, wait.Round(time.Second)))
+			return nil
+		}
+	}
+
+	// Mentioned subscribers get a highlighted whisper instead of the regular one.
+	notified := c.notifyMentions(message.Account, message.Message)
+
+	err := c.fanOut(message.Message, func(sub subscriber.Subscriber) bool {
+		_, ok := notified[sub.Account]
+		return sub.Account != message.Account && !ok && !c.inmem.IsIgnoring(sub.Account, message.Account, c.chatID)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Keep the message for those who missed it.
+	err = c.recordMessage(message.Account, postText(message.Message))
+	if err != nil {
+		return err
+	}
+
+	if c.tradeListings {
+		// Let watchers know about posts they're looking for.
+		err := c.alertWatchers(message.Account, message.Message)
+		if err != nil {
+			return err
+		}
+
+		// Keep trade posts around as listings.
+		return c.recordListings(message.Account, postText(message.Message))
+	}
+
+	return nil
+}
+
+// fanOut whispers the message to every eligible subscriber the filter accepts.
+// Callers publishing posts have to hold the publish lock to preserve message order.
+func (c *Client) fanOut(message string, filter func(sub subscriber.Subscriber) bool) error {
 	subscribers, err := c.inmem.FindEligibleSubscribers(c.chatID)
 	if err != nil {
 		return err
@@ -179,11 +385,11 @@ func (c *Client) Publish(message *Message) error {
 	for _, s := range subscribers {
 		sub := s
 
-		if sub.Account == message.Account {
+		if !filter(sub) {
 			continue
 		}
 
-		err := c.conn.Whisper(sub.Account, message.Message)
+		err := c.whisper(sub.Account, message)
 		// If there's an error, log it and continue with the next message.
 		if err != nil {
 			log.Println("failed to deliver message", err)
@@ -195,152 +401,842 @@ func (c *Client) Publish(message *Message) error {
 
 // Ban will ban the given user if the caller is allowed to ban.
 func (c *Client) Ban(message *Message) error {
-	mods, err := c.inmem.FindModerators()
-	if err != nil {
-		return err
-	}
-
-	var allowed bool
-	for _, mod := range mods {
-		if message.Account == mod {
-			allowed = true
-			break
-		}
-	}
-
-	if !allowed {
-		c.conn.Whisper(message.Account, "[insufficient privileges]")
+	if !c.allowed(message.Account, role.PermissionBan) {
+		c.whisper(message.Account, "[insufficient privileges]")
 		return nil
 	}
 
-	// Extract account to ban and days to ban from message.
-	parts := strings.Split(message.Message, " ")
+	// Extract account to ban and duration of the ban from message.
+	parts := strings.Fields(message.Message)
 
 	if len(parts) < 2 {
-		return fmt.Errorf("failed to extract data when banning, message: %s", message.Message)
+		c.whisper(message.Account, "[usage: ~ <account> <30m|6h|3d|2w|perm> [reason]]")
+		return nil
 	}
 
-	account := parts[0]
-	days, err := strconv.Atoi(strings.TrimSuffix(parts[1], "\r"))
+	account := strings.ToLower(parts[0])
+	duration, permanent, err := parseBanDuration(parts[1])
+	if err == errBanTooLong {
+		c.whisper(message.Account, fmt.Sprintf("[bans can be at most %s, use perm for longer bans]", formatDuration(maxBanDuration)))
+		return nil
+	}
 	if err != nil {
-		return err
+		c.whisper(message.Account, "[usage: ~ <account> <30m|6h|3d|2w|perm> [reason]]")
+		return nil
 	}
 
-	until := time.Now().AddDate(0, 0, days)
+	// Everything after the duration is the reason of the ban.
+	reason := strings.Join(parts[2:], " ")
+
+	// Permanent bans have no end date.
+	var until *time.Time
+	if !permanent {
+		t := time.Now().Add(duration)
+		until = &t
+	}
 
 	// Check in memory store if the account is subscribed to the chat.
 	sub := c.inmem.FindSubscriber(account, c.chatID)
 	if sub == nil {
-		c.conn.Whisper(message.Account, fmt.Sprintf("[%s not subscribed to %s]", account, c.chatID))
+		c.whisper(message.Account, fmt.Sprintf("[%s not subscribed to %s]", account, c.chatID))
 		return nil
 	}
 
 	// Subscriber exists, ban them.
-	err = c.subscribers.UpdateBannedUntil(account, c.chatID, &until)
+	err = c.subscribers.UpdateBan(account, c.chatID, until, permanent)
 	if err != nil {
 		return err
 	}
 
 	// Ban persisted, update inmem store.
-	err = c.inmem.UpdateBannedUntil(account, c.chatID, &until)
+	err = c.inmem.UpdateBan(account, c.chatID, until, permanent)
 	if err != nil {
 		return err
 	}
 
+	length := "permanently"
+	if !permanent {
+		length = "for " + formatDuration(duration)
+	}
+
+	if reason != "" {
+		length += ", reason: " + reason
+	}
+
 	// Notify moderator that the ban was complete.
-	c.conn.Whisper(message.Account, fmt.Sprintf("[%s has been banned from %s until %v]", account, c.chatID, until))
+	c.whisper(message.Account, fmt.Sprintf("[%s has been banned from %s %s]", account, c.chatID, length))
 
 	// Notify subscriber that they have been banned.
-	c.conn.Whisper(account, fmt.Sprintf("[you have been banned from %s until %v]", c.chatID, until))
+	c.whisper(account, fmt.Sprintf("[you have been banned from %s %s]", c.chatID, length))
+
+	c.logAction(moderation.Action{
+		Actor:     message.Account,
+		Target:    account,
+		Chat:      c.chatID,
+		Type:      moderation.TypeBan,
+		Reason:    reason,
+		Expiry:    until,
+		CreatedAt: time.Now(),
+	})
 
 	return nil
 }
 
-func (c *Client) subscriberBanned(sub subscriber.Subscriber) bool {
-	if sub.BannedUntil == nil {
-		return false
+// Unban lifts the ban of the given user if the caller is allowed to unban.
+func (c *Client) Unban(message *Message) error {
+	if !c.allowed(message.Account, role.PermissionUnban) {
+		c.whisper(message.Account, "[insufficient privileges]")
+		return nil
+	}
+
+	// Extract account to unban from message.
+	parts := strings.Fields(message.Message)
+	if len(parts) < 1 {
+		c.whisper(message.Account, "[usage: unban <account> [reason]]")
+		return nil
+	}
+
+	account := strings.ToLower(parts[0])
+
+	// Check in memory store if the account is subscribed to the chat.
+	sub := c.inmem.FindSubscriber(account, c.chatID)
+	if sub == nil {
+		c.whisper(message.Account, fmt.Sprintf("[%s not subscribed to %s]", account, c.chatID))
+		return nil
+	}
+
+	if !sub.Banned(time.Now()) {
+		c.whisper(message.Account, fmt.Sprintf("[%s is not banned on %s]", account, c.chatID))
+		return nil
+	}
+
+	// Subscriber is banned, lift the ban.
+	err := c.subscribers.UpdateBan(account, c.chatID, nil, false)
+	if err != nil {
+		return err
+	}
+
+	// Unban persisted, update inmem store.
+	err = c.inmem.UpdateBan(account, c.chatID, nil, false)
+	if err != nil {
+		return err
 	}
 
-	if sub.BannedUntil.After(time.Now()) {
-		// Calculate days left on ban.
-		remainder := sub.BannedUntil.Sub(time.Now())
-		days := int(remainder.Hours() / 24)
+	// Notify moderator that the unban was complete.
+	c.whisper(message.Account, fmt.Sprintf("[%s has been unbanned from %s]", account, c.chatID))
 
-		if days >= 1 {
-			c.conn.Whisper(sub.Account, fmt.Sprintf("[you are banned on %s for %d more days]", c.chatID, days))
+	// Notify subscriber that they have been unbanned.
+	c.whisper(account, fmt.Sprintf("[you have been unbanned from %s]", c.chatID))
+
+	c.logAction(moderation.Action{
+		Actor:     message.Account,
+		Target:    account,
+		Chat:      c.chatID,
+		Type:      moderation.TypeUnban,
+		Reason:    strings.Join(parts[1:], " "),
+		CreatedAt: time.Now(),
+	})
+
+	return nil
+}
+
+// ModLog whispers the latest moderation actions taken against the given account,
+// or the actions on the whole channel such as slow mode and announcements without one.
+func (c *Client) ModLog(message *Message) error {
+	if !c.allowed(message.Account, role.PermissionModLog) {
+		c.whisper(message.Account, "[insufficient privileges]")
+		return nil
+	}
+
+	// Actions on the whole channel have no target.
+	var account string
+	if parts := strings.Fields(message.Message); len(parts) > 0 {
+		account = strings.ToLower(parts[0])
+	}
+
+	// Moderators of a single channel only see what happened on it.
+	chatID := c.chatID
+	if c.allowedEverywhere(message.Account, role.PermissionModLog) {
+		chatID = ""
+	}
+
+	actions, err := c.moderation.FindActions(account, chatID, modLogLimit)
+	if err != nil {
+		return err
+	}
+
+	if len(actions) == 0 {
+		if account == "" {
+			c.whisper(message.Account, fmt.Sprintf("[no moderation actions on %s]", c.chatID))
 		} else {
-			c.conn.Whisper(sub.Account, fmt.Sprintf("[you are banned on %s for %d more hours]", c.chatID, int(remainder.Hours())))
+			c.whisper(message.Account, fmt.Sprintf("[no moderation actions against %s]", account))
 		}
+		return nil
+	}
 
-		return true
+	for _, a := range actions {
+		entry := fmt.Sprintf("%s %s", a.CreatedAt.Format("2006-01-02"), a.Type)
+		if a.Target != "" {
+			entry += " " + a.Target
+		}
+		entry += fmt.Sprintf(" on %s by %s", a.Chat, a.Actor)
+
+		if a.Type == moderation.TypeBan {
+			if a.Expiry == nil {
+				entry += ", permanent"
+			} else {
+				entry += fmt.Sprintf(", %s", formatDuration(a.Expiry.Sub(a.CreatedAt)))
+			}
+		}
+
+		if a.Reason != "" {
+			entry += ", reason: " + a.Reason
+		}
+
+		c.whisper(message.Account, fmt.Sprintf("[%s]", entry))
+	}
+
+	return nil
+}
+
+// SlowMode toggles slow mode on the channel, only allowing one message per interval for every account.
+func (c *Client) SlowMode(message *Message) error {
+	if !c.allowed(message.Account, role.PermissionSlowMode) {
+		c.whisper(message.Account, "[insufficient privileges]")
+		return nil
+	}
+
+	if c.slowMode == 0 {
+		c.whisper(message.Account, fmt.Sprintf("[slow mode is disabled on %s]", c.chatID))
+		return nil
+	}
+
+	var interval time.Duration
+
+	switch message.Message {
+	case "":
+		// Toggle slow mode using the configured interval.
+		if c.limiter.SlowMode() == 0 {
+			interval = c.slowMode
+		}
+	case "off":
+	default:
+		parsed, err := time.ParseDuration(message.Message)
+		if err != nil || parsed <= 0 {
+			c.whisper(message.Account, "[usage: slow <duration|off>, e.g. slow 30s]")
+			return nil
+		}
+		interval = parsed
+	}
+
+	c.limiter.SetSlowMode(interval)
+
+	reason := "off"
+	if interval == 0 {
+		c.whisper(message.Account, fmt.Sprintf("[slow mode disabled on %s]", c.chatID))
+	} else {
+		reason = interval.String()
+		c.whisper(message.Account, fmt.Sprintf("[slow mode on %s set to one message per %v]", c.chatID, interval))
+	}
+
+	c.logAction(moderation.Action{
+		Actor:     message.Account,
+		Chat:      c.chatID,
+		Type:      moderation.TypeSlowMode,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	})
+
+	return nil
+}
+
+// Mod grants the moderator role on the channel to the given account, or on
+// all channels if the account is followed by "all".
+func (c *Client) Mod(message *Message) error {
+	return c.updateModerator(message, true)
+}
+
+// Unmod revokes the moderator role on the channel from the given account, or
+// on all channels if the account is followed by "all".
+func (c *Client) Unmod(message *Message) error {
+	return c.updateModerator(message, false)
+}
+
+func (c *Client) updateModerator(message *Message, grant bool) error {
+	if !c.allowed(message.Account, role.PermissionManageMods) {
+		c.whisper(message.Account, "[insufficient privileges]")
+		return nil
+	}
+
+	parts := strings.Fields(message.Message)
+	if len(parts) < 1 {
+		c.whisper(message.Account, fmt.Sprintf("[usage: %s <account> [all]]", message.Cmd))
+		return nil
+	}
+
+	r := role.Role{
+		Account: strings.ToLower(parts[0]),
+		Chat:    c.chatID,
+		Name:    role.Moderator,
+	}
+
+	where := c.chatID
+	if len(parts) > 1 && strings.ToLower(parts[1]) == "all" {
+		// Moderators of every channel can only be managed by admins of every channel.
+		if !c.allowedEverywhere(message.Account, role.PermissionManageMods) {
+			c.whisper(message.Account, "[insufficient privileges]")
+			return nil
+		}
+
+		r.Chat = role.AllChats
+		where = "all channels"
+	}
+
+	var exists bool
+	for _, existing := range c.inmem.FindAccountRoles(r.Account) {
+		if existing == r {
+			exists = true
+			break
+		}
+	}
+
+	if exists == grant {
+		if grant {
+			c.whisper(message.Account, fmt.Sprintf("[%s is already a moderator on %s]", r.Account, where))
+		} else {
+			c.whisper(message.Account, fmt.Sprintf("[%s is not a moderator on %s]", r.Account, where))
+		}
+		return nil
+	}
+
+	action := moderation.TypeGrantModerator
+
+	if grant {
+		// Persist the role first.
+		err := c.subscribers.AddRole(r)
+		if err != nil {
+			return err
+		}
+
+		// Role persisted, add to inmem store which is shared by all bots.
+		err = c.inmem.AddRole(r)
+		if err != nil {
+			return err
+		}
+
+		c.whisper(message.Account, fmt.Sprintf("[%s is now a moderator on %s]", r.Account, where))
+		c.whisper(r.Account, fmt.Sprintf("[you are now a moderator on %s]", where))
+	} else {
+		action = moderation.TypeRevokeModerator
+
+		// Remove the role from the persistent store first.
+		err := c.subscribers.RemoveRole(r)
+		if err != nil {
+			return err
+		}
+
+		// Removal persisted, remove from the inmem store which is shared by all bots.
+		err = c.inmem.RemoveRole(r)
+		if err != nil {
+			return err
+		}
+
+		c.whisper(message.Account, fmt.Sprintf("[%s is no longer a moderator on %s]", r.Account, where))
+		c.whisper(r.Account, fmt.Sprintf("[you are no longer a moderator on %s]", where))
+	}
+
+	c.logAction(moderation.Action{
+		Actor:     message.Account,
+		Target:    r.Account,
+		Chat:      r.Chat,
+		Type:      action,
+		CreatedAt: time.Now(),
+	})
+
+	return nil
+}
+
+// Announce whispers an announcement to every eligible subscriber on the channel.
+func (c *Client) Announce(message *Message) error {
+	if !c.allowed(message.Account, role.PermissionAnnounce) {
+		c.whisper(message.Account, "[insufficient privileges]")
+		return nil
+	}
+
+	if message.Message == "" {
+		c.whisper(message.Account, "[usage: announce <message>]")
+		return nil
+	}
+
+	// Lock to publish in order to preserve message order integrity.
+	c.publishLock.Lock()
+	defer c.publishLock.Unlock()
+
+	subscribers, err := c.inmem.FindEligibleSubscribers(c.chatID)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subscribers {
+		err := c.whisper(sub.Account, fmt.Sprintf("[announcement] %s", message.Message))
+		// If there's an error, log it and continue with the next message.
+		if err != nil {
+			log.Println("failed to deliver announcement", err)
+		}
+	}
+
+	c.logAction(moderation.Action{
+		Actor:     message.Account,
+		Chat:      c.chatID,
+		Type:      moderation.TypeAnnounce,
+		Reason:    truncate(message.Message, maxMessageLength),
+		CreatedAt: time.Now(),
+	})
+
+	return nil
+}
+
+// logAction records a moderation action in the audit trail. The action has already
+// taken effect, so failing to record it is logged rather than failing the command.
+func (c *Client) logAction(action moderation.Action) {
+	if err := c.moderation.LogAction(action); err != nil {
+		log.Printf("failed to log moderation action %s %s", action.Type, err)
+	}
+}
+
+// allowed checks if the account holds a role granting the permission on the channel.
+func (c *Client) allowed(account string, permission string) bool {
+	for _, r := range c.inmem.FindAccountRoles(account) {
+		if r.AppliesTo(c.chatID) && r.Has(permission) {
+			return true
+		}
 	}
 
 	return false
 }
 
+// allowedEverywhere checks if the account holds a role granting the permission on every channel.
+func (c *Client) allowedEverywhere(account string, permission string) bool {
+	for _, r := range c.inmem.FindAccountRoles(account) {
+		if r.Chat == role.AllChats && r.Has(permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Client) subscriberBanned(sub subscriber.Subscriber) bool {
+	if !sub.Banned(time.Now()) {
+		return false
+	}
+
+	if sub.BannedPermanently {
+		c.whisper(sub.Account, fmt.Sprintf("[you are permanently banned on %s]", c.chatID))
+		return true
+	}
+
+	remainder := sub.BannedUntil.Sub(time.Now())
+	c.whisper(sub.Account, fmt.Sprintf("[you are banned on %s for %s more]", c.chatID, formatDuration(remainder)))
+
+	return true
+}
+
+// connect opens a new connection to the d2 server and logs in.
+func (c *Client) connect() error {
+	c.setState(StateConnecting)
+
+	// Create a new d2 tcp client.
+	client := d2client.New()
+
+	// Open connection over tcp.
+	err := client.Open(c.addr)
+	if err != nil {
+		c.setState(StateDisconnected)
+		return err
+	}
+
+	// Login with the username and password.
+	err = client.Login(c.account, c.password)
+	if err != nil {
+		client.Close()
+		c.setState(StateDisconnected)
+		return err
+	}
+
+	// Add the tcp connection to our client.
+	c.connLock.Lock()
+	c.conn = client
+	c.state = StateConnected
+	c.connLock.Unlock()
+
+	return nil
+}
+
+// reconnect will try to connect to the d2 server until it succeeds,
+// waiting with an exponential backoff between attempts.
+func (c *Client) reconnect() {
+	for attempt := 0; ; attempt++ {
+		wait := backoff(attempt)
+		log.Printf("reconnecting %s in %v", c.chatID, wait)
+		time.Sleep(wait)
+
+		c.connLock.Lock()
+		c.reconnects++
+		c.connLock.Unlock()
+
+		err := c.connect()
+		if err != nil {
+			log.Printf("failed to reconnect %s: %s", c.chatID, err)
+			continue
+		}
+
+		log.Printf("reconnected %s", c.chatID)
+		return
+	}
+}
+
+// run listens on the connection and reconnects every time it drops.
+func (c *Client) run() {
+	for {
+		c.listenAndClose()
+		c.setState(StateDisconnected)
+		c.reconnect()
+	}
+}
+
+func (c *Client) setState(state State) {
+	c.connLock.Lock()
+	defer c.connLock.Unlock()
+	c.state = state
+}
+
+// whisper queues a message to be sent to the account.
+func (c *Client) whisper(account string, message string) error {
+	return c.queue.Push(account, message)
+}
+
+// send writes a whisper to the account over the current connection.
+func (c *Client) send(account string, message string) error {
+	c.connLock.RLock()
+	defer c.connLock.RUnlock()
+
+	if c.conn == nil || c.state != StateConnected {
+		return ErrNotConnected
+	}
+
+	// Wait for the server to reply whether the whisper was delivered, the reply
+	// can be read before the write returns so it has to be pending already.
+	c.deliveries.Sent(account, time.Now())
+
+	err := c.conn.Whisper(account, message)
+	if err != nil {
+		c.deliveries.Cancel(account)
+		return err
+	}
+
+	return nil
+}
+
+// listenAndClose reads from the current connection until it fails, then closes it.
 func (c *Client) listenAndClose() {
+	c.connLock.RLock()
+	conn := c.conn
+	c.connLock.RUnlock()
+
 	// Setup channel to read on.
 	ch := make(chan []byte)
 
 	// Setup output error channel.
 	errors := make(chan error)
 
-	c.conn.Read(ch, errors)
+	conn.Read(ch, errors)
 
 	// Promise to close the connection when we're done.
-	defer c.conn.Close()
+	defer conn.Close()
+
+	// Data read that doesn't end with a full line yet.
+	var buf []byte
 
 	// Read the output from the chat onto a channel.
 	for {
 		select {
 		// This case means we recieved data on the connection.
 		case data := <-ch:
-			if decoded, valid := c.decoder.Decode(data); valid {
-				switch decoded.Cmd {
-				case TypeSubscribe:
-					err := c.Subscribe(decoded)
-					if err != nil {
-						log.Printf("failed to subscribe %s", err)
-					}
-
-				case TypeUnsubscribe:
-					err := c.Unsubscribe(decoded)
-					if err != nil {
-						log.Printf("failed to unsubscribe %s", err)
-					}
-				case TypePublish:
-					// Publish on a separate thread.
-					go func() {
-						err := c.Publish(decoded)
-						if err != nil {
-							log.Printf("failed to publish %s", err)
-						}
-					}()
-				case TypeBan:
-					err := c.Ban(decoded)
-					if err != nil {
-						log.Printf("failed to ban %s", err)
-					}
-				default:
-					log.Printf("unknown cmd received: %s", decoded.Cmd)
+			buf = append(buf, data...)
+
+			// Handle every complete line, keeping the rest until more data arrives.
+			for {
+				i := bytes.IndexByte(buf, '\n')
+				if i < 0 {
+					break
+				}
+
+				line := bytes.TrimRight(buf[:i], "\r")
+				buf = buf[i+1:]
+
+				if len(line) > 0 {
+					c.handleLine(line)
 				}
 			}
 
 		case err := <-errors:
 			log.Println("got error while listening on client output", err)
-			break
+			return
+		}
+	}
+}
+
+// handleLine handles a single line of output from the server.
+func (c *Client) handleLine(line []byte) {
+	if decoded, valid := c.decoder.Decode(line); valid {
+		c.handle(decoded)
+		return
+	}
+
+	if sender, ok := c.decoder.Sender(line); ok {
+		// Someone whispered the bot something that isn't a command, point them to help.
+		c.Hint(sender)
+		return
+	}
+
+	if account, ok := c.decoder.Echo(line); ok {
+		c.deliveries.Delivered(account, time.Now())
+		return
+	}
+
+	if reason, ok := c.decoder.Failure(line); ok {
+		err := c.deliveryFailed(reason)
+		if err != nil {
+			log.Printf("failed to handle delivery failure %s", err)
 		}
 	}
 }
 
+// deliveryFailed counts a failure for the account of a whisper that couldn't be delivered,
+// marking it offline if it isn't logged on.
+func (c *Client) deliveryFailed(reason string) error {
+	account, ok := c.deliveries.Failed(time.Now())
+	if !ok {
+		return nil
+	}
+
+	log.Printf("failed to deliver whisper to %s on %s: %s", account, c.chatID, reason)
+
+	// Squelching only affects this bot, the account is still online for the others.
+	if reason != FailureNotLoggedOn {
+		return nil
+	}
+
+	// Only subscribers have an online state to correct.
+	sub := c.inmem.FindSubscriber(account, c.chatID)
+	if sub == nil || !sub.Online {
+		return nil
+	}
+
+	// Update persistent store first.
+	err := c.subscribers.UpdateOnlineStatus(account, false)
+	if err != nil {
+		return err
+	}
+
+	// Update persisted, update the inmem store.
+	return c.inmem.UpdateOnlineStatus(account, false)
+}
+
+// DeliveryFailures returns the number of whispers to the account that couldn't be delivered.
+func (c *Client) DeliveryFailures(account string) int {
+	return c.deliveries.Failures(account)
+}
+
+// handle dispatches a decoded message to the matching command.
+func (c *Client) handle(decoded *Message) {
+	switch decoded.Cmd {
+	case TypeSubscribe:
+		err := c.Subscribe(decoded)
+		if err != nil {
+			log.Printf("failed to subscribe %s", err)
+		}
+
+	case TypeUnsubscribe:
+		err := c.Unsubscribe(decoded)
+		if err != nil {
+			log.Printf("failed to unsubscribe %s", err)
+		}
+	case TypePublish:
+		// Publish on a separate thread.
+		go func() {
+			err := c.Publish(decoded)
+			if err != nil {
+				log.Printf("failed to publish %s", err)
+			}
+		}()
+	case TypeBan:
+		err := c.Ban(decoded)
+		if err != nil {
+			log.Printf("failed to ban %s", err)
+		}
+	case TypeUnban:
+		err := c.Unban(decoded)
+		if err != nil {
+			log.Printf("failed to unban %s", err)
+		}
+	case TypeMod:
+		err := c.Mod(decoded)
+		if err != nil {
+			log.Printf("failed to add moderator %s", err)
+		}
+	case TypeUnmod:
+		err := c.Unmod(decoded)
+		if err != nil {
+			log.Printf("failed to remove moderator %s", err)
+		}
+	case TypeHelp:
+		err := c.Help(decoded)
+		if err != nil {
+			log.Printf("failed to list commands %s", err)
+		}
+	case TypeWho:
+		err := c.Who(decoded)
+		if err != nil {
+			log.Printf("failed to list subscribers %s", err)
+		}
+	case TypeSeen:
+		err := c.Seen(decoded)
+		if err != nil {
+			log.Printf("failed to find last seen %s", err)
+		}
+	case TypeAnnounce:
+		err := c.Announce(decoded)
+		if err != nil {
+			log.Printf("failed to announce %s", err)
+		}
+	case TypeModLog:
+		err := c.ModLog(decoded)
+		if err != nil {
+			log.Printf("failed to find moderation log %s", err)
+		}
+	case TypeGames:
+		err := c.Games(decoded)
+		if err != nil {
+			log.Printf("failed to update game announcements %s", err)
+		}
+	case TypeLFG:
+		err := c.LFG(decoded)
+		if err != nil {
+			log.Printf("failed to handle lfg %s", err)
+		}
+	case TypeFind:
+		err := c.Find(decoded)
+		if err != nil {
+			log.Printf("failed to find listings %s", err)
+		}
+	case TypeListings:
+		err := c.Listings(decoded)
+		if err != nil {
+			log.Printf("failed to list listings %s", err)
+		}
+	case TypeUnlist:
+		err := c.Unlist(decoded)
+		if err != nil {
+			log.Printf("failed to remove listing %s", err)
+		}
+	case TypeWatch:
+		err := c.Watch(decoded)
+		if err != nil {
+			log.Printf("failed to watch %s", err)
+		}
+	case TypeUnwatch:
+		err := c.Unwatch(decoded)
+		if err != nil {
+			log.Printf("failed to unwatch %s", err)
+		}
+	case TypeHistory:
+		err := c.History(decoded)
+		if err != nil {
+			log.Printf("failed to list history %s", err)
+		}
+	case TypeDigest:
+		err := c.Digest(decoded)
+		if err != nil {
+			log.Printf("failed to update digest %s", err)
+		}
+	case TypeMentions:
+		err := c.Mentions(decoded)
+		if err != nil {
+			log.Printf("failed to update mentions %s", err)
+		}
+	case TypeIgnore:
+		err := c.Ignore(decoded)
+		if err != nil {
+			log.Printf("failed to ignore %s", err)
+		}
+	case TypeUnignore:
+		err := c.Unignore(decoded)
+		if err != nil {
+			log.Printf("failed to unignore %s", err)
+		}
+	case TypeSlowMode:
+		err := c.SlowMode(decoded)
+		if err != nil {
+			log.Printf("failed to set slow mode %s", err)
+		}
+	default:
+		log.Printf("unknown cmd received: %s", decoded.Cmd)
+	}
+}
+
+// backoff returns the time to wait before the given reconnect attempt,
+// doubling for every attempt up to maxBackoff, with jitter applied.
+func backoff(attempt int) time.Duration {
+	wait := maxBackoff
+	if attempt < 16 {
+		wait = minBackoff << uint(attempt)
+		if wait > maxBackoff {
+			wait = maxBackoff
+		}
+	}
+
+	// Randomize between half and the full wait so bots don't reconnect in lockstep.
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
 // New will create a new Client with all dependencies set up.
-func New(addr string, chatID string, password string, inmem inmemRepository, subscribers subscriberRepository) *Client {
-	return &Client{
-		addr:        addr,
-		chatID:      chatID,
-		password:    password,
-		decoder:     decoder{},
-		inmem:       inmem,
-		subscribers: subscribers,
+func New(addr string, chatID string, account string, password string, opts Options, inmem inmemRepository, subscribers subscriberRepository, moderation moderationRepository, presence presenceRepository, trades tradeRepository, messages historyRepository) *Client {
+	c := &Client{
+		addr:             addr,
+		chatID:           chatID,
+		account:          account,
+		password:         password,
+		decoder:          decoder{},
+		deliveries:       newDeliveries(),
+		games:            newCreatedGames(),
+		lfg:              newLFGBoard(),
+		lfgExpiry:        opts.LFGExpiry,
+		tradeListings:    opts.TradeListings,
+		listingExpiry:    opts.ListingExpiry,
+		history:          newRing(opts.HistorySize),
+		historyRetention: opts.HistoryRetention,
+		limiter:          newLimiter(opts.RateLimit, opts.RateWindow),
+		helps:            newLimiter(helpLimit, helpWindow),
+		hints:            newLimiter(1, helpWindow),
+		bots:             make(map[string]bool),
+		slowMode:         opts.SlowMode,
+		inmem:            inmem,
+		subscribers:      subscribers,
+		moderation:       moderation,
+		presence:         presence,
+		trades:           trades,
+		messages:         messages,
+	}
+
+	c.queue = newQueue(c.send, opts.WhisperRate, opts.WhisperBurst, opts.WhisperQueueSize)
+
+	c.bots[strings.ToLower(account)] = true
+	for _, bot := range opts.Bots {
+		c.bots[strings.ToLower(bot)] = true
 	}
+
+	return c
 }
@@ -0,0 +1,228 @@
+package client
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/inmem"
+	"github.com/nokka/d2-chatbot/internal/role"
+	"github.com/nokka/d2-chatbot/internal/subscriber"
+)
+
+// newTestClient returns a client on the chat backed by the inmem repository, with
+// whispers queued for the test to read with whispers.
+func newTestClient(chatID string, repo *inmem.SubscriberRepository) *Client {
+	return &Client{
+		chatID:      chatID,
+		account:     chatID,
+		inmem:       repo,
+		subscribers: repo,
+		queue:       newQueue(func(account string, message string) error { return nil }, 1, 1, 100),
+		helps:       newLimiter(helpLimit, helpWindow),
+		hints:       newLimiter(1, helpWindow),
+		bots:        map[string]bool{chatID: true},
+	}
+}
+
+// whispers drains the whispers queued by the client.
+func whispers(c *Client) []string {
+	var messages []string
+	for {
+		select {
+		case w := <-c.queue.items:
+			messages = append(messages, w.message)
+		default:
+			return messages
+		}
+	}
+}
+
+func TestAllowed(t *testing.T) {
+	repo := inmem.NewSubscriberRepository()
+	repo.SyncRoles([]role.Role{
+		{Account: "nokka", Chat: "chat", Name: role.Moderator},
+		{Account: "meanski", Chat: "chat", Name: role.Admin},
+		{Account: "bruse", Chat: role.AllChats, Name: role.Admin},
+	})
+
+	c := newTestClient("chat", repo)
+	trade := newTestClient("trade", repo)
+
+	tests := []struct {
+		name       string
+		client     *Client
+		account    string
+		permission string
+		allowed    bool
+		everywhere bool
+	}{
+		{name: "moderator on chat", client: c, account: "nokka", permission: role.PermissionBan, allowed: true},
+		{name: "moderator without permission", client: c, account: "nokka", permission: role.PermissionAnnounce},
+		{name: "moderator on other chat", client: trade, account: "nokka", permission: role.PermissionBan},
+		{name: "chat admin", client: c, account: "meanski", permission: role.PermissionManageMods, allowed: true},
+		{name: "chat admin on other chat", client: trade, account: "meanski", permission: role.PermissionManageMods},
+		{name: "global admin", client: trade, account: "bruse", permission: role.PermissionManageMods, allowed: true, everywhere: true},
+		{name: "no roles", client: c, account: "trog", permission: role.PermissionBan},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if allowed := tt.client.allowed(tt.account, tt.permission); allowed != tt.allowed {
+				t.Fatalf("expected allowed: %v, got: %v", tt.allowed, allowed)
+			}
+
+			if everywhere := tt.client.allowedEverywhere(tt.account, tt.permission); everywhere != tt.everywhere {
+				t.Fatalf("expected allowed everywhere: %v, got: %v", tt.everywhere, everywhere)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{attempt: 0, max: minBackoff},
+		{attempt: 1, max: 2 * time.Second},
+		{attempt: 3, max: 8 * time.Second},
+		{attempt: 6, max: 64 * time.Second},
+		{attempt: 7, max: maxBackoff},
+		{attempt: 16, max: maxBackoff},
+		{attempt: 100, max: maxBackoff},
+	}
+
+	for _, tt := range tests {
+		// Jitter randomizes the wait, try a few times to cover the range.
+		for i := 0; i < 100; i++ {
+			wait := backoff(tt.attempt)
+			if wait < tt.max/2 || wait > tt.max {
+				t.Fatalf("attempt %d: expected between %v and %v, got: %v", tt.attempt, tt.max/2, tt.max, wait)
+			}
+		}
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer ln.Close()
+
+	conns := make(chan net.Conn, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+		}
+	}()
+
+	c := &Client{chatID: "chat", addr: ln.Addr().String(), decoder: decoder{}}
+
+	if state := c.State(); state != StateDisconnected {
+		t.Fatalf("expected %v before connecting, got: %v", StateDisconnected, state)
+	}
+
+	err = c.connect()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if state := c.State(); state != StateConnected {
+		t.Fatalf("expected %v, got: %v", StateConnected, state)
+	}
+
+	go c.run()
+
+	// Drop the connection from the server side.
+	conn := <-conns
+	conn.Close()
+
+	if !waitFor(func() bool { return c.State() == StateDisconnected }) {
+		t.Fatalf("expected %v after the connection dropped, got: %v", StateDisconnected, c.State())
+	}
+
+	if !waitFor(func() bool { return c.State() == StateConnected }) {
+		t.Fatalf("expected %v after reconnecting, got: %v", StateConnected, c.State())
+	}
+
+	if attempts := c.ReconnectAttempts(); attempts != 1 {
+		t.Fatalf("expected 1 reconnect attempt, got: %d", attempts)
+	}
+}
+
+func TestDeliveryFailed(t *testing.T) {
+	repo := inmem.NewSubscriberRepository()
+	repo.SyncSubscribers("chat", []subscriber.Subscriber{
+		{Account: "nokka", Online: true},
+		{Account: "meanski", Online: true},
+		{Account: "bruse", Online: true},
+	})
+
+	c := newTestClient("chat", repo)
+	c.deliveries = newDeliveries()
+
+	now := time.Now()
+	c.deliveries.Sent("nokka", now)
+	c.deliveries.Sent("meanski", now)
+	c.deliveries.Sent("bruse", now)
+
+	// nokka's whisper was delivered, meanski isn't logged on and bruse is squelching the bot.
+	c.handleLine([]byte("<to nokka> [chat] hello"))
+	c.handleLine([]byte("That user is not logged on."))
+	c.handleLine([]byte("bruse is squelching you."))
+
+	online := map[string]bool{"nokka": true, "meanski": false, "bruse": true}
+	for account, want := range online {
+		if got := repo.FindSubscriber(account, "chat").Online; got != want {
+			t.Fatalf("expected %s online = %v; got = %v", account, want, got)
+		}
+	}
+
+	failures := map[string]int{"nokka": 0, "meanski": 1, "bruse": 1}
+	for account, want := range failures {
+		if got := c.DeliveryFailures(account); got != want {
+			t.Fatalf("expected %d failures for %s; got = %d", want, account, got)
+		}
+	}
+}
+
+func TestConnectFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing listens on the address anymore.
+	addr := ln.Addr().String()
+	ln.Close()
+
+	c := &Client{chatID: "chat", addr: addr}
+
+	if err := c.connect(); err == nil {
+		t.Fatal("expected an error connecting")
+	}
+
+	if state := c.State(); state != StateDisconnected {
+		t.Fatalf("expected %v, got: %v", StateDisconnected, state)
+	}
+}
+
+// waitFor polls the condition until it holds or a few seconds pass.
+func waitFor(condition func() bool) bool {
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return false
+}
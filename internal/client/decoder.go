@@ -6,8 +6,26 @@ import (
 	"strings"
 )
 
-// Compile the regex once.
-var r = regexp.MustCompile(`(?i)^<from\s+([a-z0-9_\-]+)>\s+([#@!\~]{1})\s*(.+)?`)
+// Compile the regex once, commands are either a single symbol or a word.
+var r = regexp.MustCompile(`(?i)^<from\s+([a-z0-9_\-]+)>\s+([#@!\~]{1}|[a-z]+\b)\s*(.+)?`)
+
+// Any whisper, used to find the sender of whispers that aren't commands.
+var whisperRegex = regexp.MustCompile(`(?i)^<from\s+([a-z0-9_\-]+)>`)
+
+// Echo of a whisper the bot sent, meaning it was delivered.
+var echoRegex = regexp.MustCompile(`(?i)^<to\s+([a-z0-9_\-]+)>`)
+
+// Server errors replying to a whisper that couldn't be delivered.
+var (
+	notLoggedOnRegex = regexp.MustCompile(`(?i)^that user is not logged on`)
+	squelchedRegex   = regexp.MustCompile(`(?i)^[a-z0-9_\-]+ is (ignoring|squelching) you`)
+)
+
+// Reasons a whisper wasn't delivered.
+const (
+	FailureNotLoggedOn = "not logged on"
+	FailureSquelched   = "squelched"
+)
 
 // IP address regex to remove sensitive information when replying.
 var ipregx = regexp.MustCompile(`(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)(\.(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)){3}`)
@@ -22,6 +40,27 @@ const (
 	TypeUnsubscribe = "!"
 	TypePublish     = "#"
 	TypeBan         = "~"
+	TypeUnban       = "unban"
+	TypeModLog      = "modlog"
+	TypeMod         = "mod"
+	TypeUnmod       = "unmod"
+	TypeAnnounce    = "announce"
+	TypeHelp        = "help"
+	TypeWho         = "who"
+	TypeSeen        = "seen"
+	TypeSlowMode    = "slow"
+	TypeGames       = "games"
+	TypeLFG         = "lfg"
+	TypeFind        = "find"
+	TypeListings    = "listings"
+	TypeUnlist      = "unlist"
+	TypeWatch       = "watch"
+	TypeUnwatch     = "unwatch"
+	TypeHistory     = "history"
+	TypeDigest      = "digest"
+	TypeMentions    = "mentions"
+	TypeIgnore      = "ignore"
+	TypeUnignore    = "unignore"
 
 	// Indices.
 	account = 1
@@ -35,6 +74,27 @@ var cmds = map[string]struct{}{
 	TypePublish:     {},
 	TypeUnsubscribe: {},
 	TypeBan:         {},
+	TypeUnban:       {},
+	TypeModLog:      {},
+	TypeMod:         {},
+	TypeUnmod:       {},
+	TypeAnnounce:    {},
+	TypeHelp:        {},
+	TypeWho:         {},
+	TypeSeen:        {},
+	TypeSlowMode:    {},
+	TypeGames:       {},
+	TypeLFG:         {},
+	TypeFind:        {},
+	TypeListings:    {},
+	TypeUnlist:      {},
+	TypeWatch:       {},
+	TypeUnwatch:     {},
+	TypeHistory:     {},
+	TypeDigest:      {},
+	TypeMentions:    {},
+	TypeIgnore:      {},
+	TypeUnignore:    {},
 }
 
 // Message is the message decoded.
@@ -52,14 +112,16 @@ func (d decoder) Decode(data []byte) (*Message, bool) {
 		return nil, false
 	}
 
+	command := strings.ToLower(matches[cmd])
+
 	// Return invalid if the cmd isn't allowed.
-	if _, ok := cmds[matches[cmd]]; !ok {
+	if _, ok := cmds[command]; !ok {
 		return nil, false
 	}
 
 	message := &Message{
 		Account: strings.ToLower(matches[account]),
-		Cmd:     matches[cmd],
+		Cmd:     command,
 	}
 
 	// Clean up message from IP address that can accidentally
@@ -71,7 +133,45 @@ func (d decoder) Decode(data []byte) (*Message, bool) {
 		message.Message = fmt.Sprintf("[%s] %s", matches[account], processed)
 	case TypeBan:
 		message.Message = matches[msg]
+	case TypeSubscribe, TypeUnsubscribe:
+	default:
+		// Word commands get their arguments without surrounding whitespace.
+		message.Message = strings.TrimSpace(processed)
 	}
 
 	return message, true
 }
+
+// Sender returns the account of any incoming whisper, valid command or not.
+func (d decoder) Sender(data []byte) (string, bool) {
+	matches := whisperRegex.FindStringSubmatch(string(data))
+
+	if len(matches) != 2 {
+		return "", false
+	}
+
+	return strings.ToLower(matches[account]), true
+}
+
+// Echo returns the account of a whisper the bot sent, echoed back by the server when delivered.
+func (d decoder) Echo(data []byte) (string, bool) {
+	matches := echoRegex.FindStringSubmatch(string(data))
+
+	if len(matches) != 2 {
+		return "", false
+	}
+
+	return strings.ToLower(matches[account]), true
+}
+
+// Failure returns the reason of a server error replying to a whisper that couldn't be delivered.
+func (d decoder) Failure(data []byte) (string, bool) {
+	switch {
+	case notLoggedOnRegex.Match(data):
+		return FailureNotLoggedOn, true
+	case squelchedRegex.Match(data):
+		return FailureSquelched, true
+	default:
+		return "", false
+	}
+}
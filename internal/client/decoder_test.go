@@ -93,10 +93,44 @@ func TestDecode(t *testing.T) {
 			msg: &Message{
 				Account: "nokka",
 				Cmd:     TypeBan,
-				Message: "cheatingaccount 5",
+				Message: "nokka_bo 25",
 			},
 			valid: true,
 		},
+		{
+			name:  "valid unban",
+			input: []byte("<from nokka> unban nokka_bo\r"),
+			msg: &Message{
+				Account: "nokka",
+				Cmd:     TypeUnban,
+				Message: "nokka_bo",
+			},
+			valid: true,
+		},
+		{
+			name:  "valid slow mode",
+			input: []byte("<from nokka> slow 30s\r"),
+			msg: &Message{
+				Account: "nokka",
+				Cmd:     TypeSlowMode,
+				Message: "30s",
+			},
+			valid: true,
+		},
+		{
+			name:  "valid slow mode - uppercase",
+			input: []byte("<from Nokka> SLOW"),
+			msg: &Message{
+				Account: "nokka",
+				Cmd:     TypeSlowMode,
+			},
+			valid: true,
+		},
+		{
+			name:  "invalid word command",
+			input: []byte("<from nokka> slowly"),
+			valid: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -113,3 +147,100 @@ func TestDecode(t *testing.T) {
 		})
 	}
 }
+
+func TestSender(t *testing.T) {
+	decoder := decoder{}
+
+	tests := []struct {
+		name    string
+		input   []byte
+		account string
+		valid   bool
+	}{
+		{
+			name:    "command",
+			input:   []byte("<from nokka> @"),
+			account: "nokka",
+			valid:   true,
+		},
+		{
+			name:    "random whisper",
+			input:   []byte("<from Nokka> random message that won't get through"),
+			account: "nokka",
+			valid:   true,
+		},
+		{
+			name:  "own whisper",
+			input: []byte("<to nokka> [subscribed chat]"),
+			valid: false,
+		},
+		{
+			name:  "server message",
+			input: []byte("That user is not logged on."),
+			valid: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account, valid := decoder.Sender(tt.input)
+
+			if tt.valid != valid {
+				t.Fatalf("expected valid = %v; got = %v", tt.valid, valid)
+			}
+
+			if tt.account != account {
+				t.Fatalf("expected: %v, got: %v", tt.account, account)
+			}
+		})
+	}
+}
+
+func TestReplies(t *testing.T) {
+	decoder := decoder{}
+
+	tests := []struct {
+		name    string
+		input   []byte
+		echo    string
+		failure string
+	}{
+		{
+			name:  "echo",
+			input: []byte("<to Nokka> [subscribed chat]"),
+			echo:  "nokka",
+		},
+		{
+			name:    "not logged on",
+			input:   []byte("That user is not logged on."),
+			failure: FailureNotLoggedOn,
+		},
+		{
+			name:    "squelched",
+			input:   []byte("nokka is ignoring you."),
+			failure: FailureSquelched,
+		},
+		{
+			name:  "whisper",
+			input: []byte("<from nokka> # hello"),
+		},
+		{
+			name:  "channel talk",
+			input: []byte("<nokka> meanski is ignoring you"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			echo, _ := decoder.Echo(tt.input)
+			if tt.echo != echo {
+				t.Fatalf("expected echo = %q; got = %q", tt.echo, echo)
+			}
+
+			failure, _ := decoder.Failure(tt.input)
+			if tt.failure != failure {
+				t.Fatalf("expected failure = %q; got = %q", tt.failure, failure)
+			}
+		})
+	}
+}
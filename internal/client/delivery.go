@@ -0,0 +1,112 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// pendingTimeout is how long a whisper waits for a reply before it's forgotten.
+	pendingTimeout = 10 * time.Second
+
+	// maxPending is the max number of whispers waiting for a reply.
+	maxPending = 1000
+)
+
+// pending is a whisper sent to an account, waiting for a reply from the server.
+type pending struct {
+	account string
+	sentAt  time.Time
+}
+
+// deliveries keeps track of whispers waiting for a reply. PvpGN replies to every
+// whisper in order, with an echo when it was delivered or an error when it
+// wasn't, the error doesn't say who it's about so it's matched by order.
+type deliveries struct {
+	mu       sync.Mutex
+	pending  []pending
+	failures map[string]int
+}
+
+// Sent records a whisper sent to the account.
+func (d *deliveries) Sent(account string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expire(now)
+
+	if len(d.pending) >= maxPending {
+		d.pending = d.pending[1:]
+	}
+
+	d.pending = append(d.pending, pending{account: account, sentAt: now})
+}
+
+// Cancel removes the latest whisper to the account, for whispers that couldn't be written.
+func (d *deliveries) Cancel(account string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for i := len(d.pending) - 1; i >= 0; i-- {
+		if d.pending[i].account == account {
+			d.pending = append(d.pending[:i:i], d.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// Delivered removes the oldest whisper to the account, and every whisper sent before it.
+func (d *deliveries) Delivered(account string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expire(now)
+
+	for i, p := range d.pending {
+		if p.account == account {
+			d.pending = d.pending[i+1:]
+			return
+		}
+	}
+}
+
+// Failed removes the oldest whisper and counts a delivery failure for its account.
+func (d *deliveries) Failed(now time.Time) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expire(now)
+
+	if len(d.pending) == 0 {
+		return "", false
+	}
+
+	account := d.pending[0].account
+	d.pending = d.pending[1:]
+	d.failures[account]++
+
+	return account, true
+}
+
+// Failures returns the number of whispers to the account that failed.
+func (d *deliveries) Failures(account string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.failures[account]
+}
+
+// expire forgets whispers that never got a reply.
+func (d *deliveries) expire(now time.Time) {
+	var i int
+	for i < len(d.pending) && now.Sub(d.pending[i].sentAt) > pendingTimeout {
+		i++
+	}
+
+	d.pending = d.pending[i:]
+}
+
+func newDeliveries() *deliveries {
+	return &deliveries{
+		failures: make(map[string]int),
+	}
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeliveries(t *testing.T) {
+	now := time.Date(2020, 8, 28, 8, 0, 0, 0, time.UTC)
+
+	d := newDeliveries()
+	d.Sent("nokka", now)
+	d.Sent("meanski", now)
+	d.Sent("chat", now)
+
+	// The first whisper was delivered, the second failed.
+	d.Delivered("nokka", now)
+
+	account, ok := d.Failed(now)
+	if !ok || account != "meanski" {
+		t.Fatalf("expected failure for meanski; got = %q, %v", account, ok)
+	}
+
+	if failures := d.Failures("meanski"); failures != 1 {
+		t.Fatalf("expected 1 failure; got = %d", failures)
+	}
+
+	// The last whisper never got a reply and is forgotten.
+	account, ok = d.Failed(now.Add(pendingTimeout + time.Second))
+	if ok {
+		t.Fatalf("expected no pending whispers; got = %q", account)
+	}
+}
+
+func TestDeliveriesCancel(t *testing.T) {
+	now := time.Date(2020, 8, 28, 8, 0, 0, 0, time.UTC)
+
+	d := newDeliveries()
+	d.Sent("nokka", now)
+	d.Sent("meanski", now)
+	d.Sent("nokka", now)
+
+	// The last whisper to nokka couldn't be written.
+	d.Cancel("nokka")
+
+	for _, want := range []string{"nokka", "meanski"} {
+		account, ok := d.Failed(now)
+		if !ok || account != want {
+			t.Fatalf("expected failure for %s; got = %q, %v", want, account, ok)
+		}
+	}
+
+	if account, ok := d.Failed(now); ok {
+		t.Fatalf("expected no pending whispers; got = %q", account)
+	}
+}
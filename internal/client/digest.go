@@ -0,0 +1,182 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/history"
+)
+
+const (
+	// digestScanLimit is the max number of messages summarised in a digest.
+	digestScanLimit = 500
+
+	// maxDigestLines is the max number of messages whispered in a digest, mentions first.
+	maxDigestLines = 5
+
+	// digestAccounts is the number of accounts named in a digest.
+	digestAccounts = 3
+)
+
+// Digest turns the digest of what was said while the caller was offline on or off.
+func (c *Client) Digest(message *Message) error {
+	sub := c.inmem.FindSubscriber(message.Account, c.chatID)
+	if sub == nil {
+		c.whisper(message.Account, fmt.Sprintf("[not subscribed to %s]", c.chatID))
+		return nil
+	}
+
+	var enabled bool
+	switch strings.ToLower(message.Message) {
+	case "":
+		if sub.Digest {
+			c.whisper(message.Account, fmt.Sprintf("[digest is on for %s, whisper %s off to turn it off]", c.chatID, TypeDigest))
+		} else {
+			c.whisper(message.Account, fmt.Sprintf("[digest is off for %s, whisper %s on to get what you missed when logging in]", c.chatID, TypeDigest))
+		}
+		return nil
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		c.whisper(message.Account, fmt.Sprintf("[usage: %s [on|off]]", TypeDigest))
+		return nil
+	}
+
+	// Update persistent store first.
+	err := c.subscribers.UpdateDigest(message.Account, c.chatID, enabled)
+	if err != nil {
+		return err
+	}
+
+	// Update persisted, update the inmem store.
+	err = c.inmem.UpdateDigest(message.Account, c.chatID, enabled)
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		c.whisper(message.Account, fmt.Sprintf("[digest turned on for %s]", c.chatID))
+	} else {
+		c.whisper(message.Account, fmt.Sprintf("[digest turned off for %s]", c.chatID))
+	}
+
+	return nil
+}
+
+// loggedIn delivers the mentions queued for subscribers logging in,
+// followed by the digest if they want one.
+func (c *Client) loggedIn(account string) {
+	// Banned subscribers get nothing, without being reminded of the ban on every login.
+	sub := c.inmem.FindSubscriber(account, c.chatID)
+	if sub == nil || sub.Banned(time.Now()) {
+		return
+	}
+
+	if !sub.MentionsDisabled {
+		if err := c.deliverMentions(account); err != nil {
+			log.Printf("failed to deliver mentions %s", err)
+		}
+	}
+
+	if !sub.Digest {
+		return
+	}
+
+	// Mentions were just delivered, so the digest only needs them when they're turned off.
+	if err := c.sendDigest(account, sub.MentionsDisabled); err != nil {
+		log.Printf("failed to send digest %s", err)
+	}
+}
+
+// sendDigest whispers a summary of the messages published since the account
+// logged out, followed by the latest messages, with those mentioning it first
+// if withMentions is set and left out otherwise.
+func (c *Client) sendDigest(account string, withMentions bool) error {
+	p, err := c.presence.FindPresence(account)
+	if err != nil {
+		return err
+	}
+
+	// Nothing to catch up on for accounts that never logged out.
+	if p == nil || p.LastLogout == nil {
+		return nil
+	}
+
+	found, err := c.messages.FindMessages(c.chatID, *p.LastLogout, digestScanLimit)
+	if err != nil {
+		return err
+	}
+
+	// The account knows what it said itself, and doesn't want to hear from accounts it ignores.
+	messages := make([]history.Message, 0, len(found))
+	for _, m := range found {
+		if m.Account != account && !c.inmem.IsIgnoring(account, m.Account, c.chatID) {
+			messages = append(messages, m)
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	c.whisper(account, fmt.Sprintf("[%d messages on %s by %s since you logged out %s ago]", len(messages), c.chatID, digestAuthors(messages), formatDuration(now.Sub(*p.LastLogout))))
+
+	// Mentions come first, then the latest messages fill up the digest.
+	included := make(map[int]struct{})
+	for i := 0; i < len(messages) && len(included) < maxDigestLines && withMentions; i++ {
+		if mentioned(messages[i].Text, account) {
+			included[i] = struct{}{}
+		}
+	}
+
+	for i := len(messages) - 1; i >= 0 && len(included) < maxDigestLines; i-- {
+		if withMentions || !mentioned(messages[i].Text, account) {
+			included[i] = struct{}{}
+		}
+	}
+
+	for i, m := range messages {
+		if _, ok := included[i]; ok {
+			c.whisper(account, fmt.Sprintf("[%s ago] [%s] %s", formatDuration(now.Sub(m.CreatedAt)), m.Account, m.Text))
+		}
+	}
+
+	return nil
+}
+
+// digestAuthors names the accounts that published the messages, such as "nokka, meanski and 3 others".
+func digestAuthors(messages []history.Message) string {
+	var (
+		accounts []string
+		seen     = make(map[string]struct{})
+	)
+
+	for _, m := range messages {
+		if _, ok := seen[m.Account]; !ok {
+			seen[m.Account] = struct{}{}
+			accounts = append(accounts, m.Account)
+		}
+	}
+
+	if len(accounts) <= digestAccounts {
+		if len(accounts) == 1 {
+			return accounts[0]
+		}
+
+		return strings.Join(accounts[:len(accounts)-1], ", ") + " and " + accounts[len(accounts)-1]
+	}
+
+	others := len(accounts) - digestAccounts
+	name := "others"
+	if others == 1 {
+		name = "other"
+	}
+
+	return fmt.Sprintf("%s and %d %s", strings.Join(accounts[:digestAccounts], ", "), others, name)
+}
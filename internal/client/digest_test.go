@@ -0,0 +1,34 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/nokka/d2-chatbot/internal/history"
+)
+
+func TestDigestAuthors(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts []string
+		authors  string
+	}{
+		{name: "single", accounts: []string{"nokka", "nokka"}, authors: "nokka"},
+		{name: "two", accounts: []string{"nokka", "meanski", "nokka"}, authors: "nokka and meanski"},
+		{name: "three", accounts: []string{"nokka", "meanski", "bruse"}, authors: "nokka, meanski and bruse"},
+		{name: "one other", accounts: []string{"nokka", "meanski", "bruse", "trog"}, authors: "nokka, meanski, bruse and 1 other"},
+		{name: "others", accounts: []string{"nokka", "meanski", "bruse", "trog", "frog"}, authors: "nokka, meanski, bruse and 2 others"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []history.Message
+			for _, account := range tt.accounts {
+				messages = append(messages, history.Message{Account: account})
+			}
+
+			if authors := digestAuthors(messages); authors != tt.authors {
+				t.Fatalf("expected: %v, got: %v", tt.authors, authors)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxBanDuration is the longest temporary ban, longer bans have to be permanent.
+const maxBanDuration = 52 * 7 * 24 * time.Hour
+
+// errBanTooLong is returned for ban durations longer than maxBanDuration.
+var errBanTooLong = errors.New("ban duration too long")
+
+// Ban durations are a number followed by a unit, a number without unit is days.
+var durationRegex = regexp.MustCompile(`(?i)^(\d+)([mhdw]?)$`)
+
+// Units used when parsing and formatting durations.
+var units = []struct {
+	suffix   string
+	name     string
+	duration time.Duration
+}{
+	{"w", "week", 7 * 24 * time.Hour},
+	{"d", "day", 24 * time.Hour},
+	{"h", "hour", time.Hour},
+	{"m", "minute", time.Minute},
+}
+
+// parseBanDuration parses ban durations such as 30m, 6h, 3d, 2w or perm.
+// It returns true if the ban is permanent.
+func parseBanDuration(s string) (time.Duration, bool, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	if s == "perm" || s == "permanent" {
+		return 0, true, nil
+	}
+
+	matches := durationRegex.FindStringSubmatch(s)
+	if len(matches) != 3 {
+		return 0, false, fmt.Errorf("invalid duration %q", s)
+	}
+
+	n, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return 0, false, err
+	}
+
+	if n <= 0 {
+		return 0, false, fmt.Errorf("invalid duration %q", s)
+	}
+
+	unit := matches[2]
+	if unit == "" {
+		unit = "d"
+	}
+
+	for _, u := range units {
+		if u.suffix == unit {
+			// Compare before multiplying, large numbers overflow the duration.
+			if int64(n) > int64(maxBanDuration/u.duration) {
+				return 0, false, errBanTooLong
+			}
+
+			return time.Duration(n) * u.duration, false, nil
+		}
+	}
+
+	return 0, false, fmt.Errorf("invalid duration unit %q", unit)
+}
+
+// formatDuration renders a duration in words, using at most the two largest units, e.g. 2 days 5 hours.
+func formatDuration(d time.Duration) string {
+	var parts []string
+
+	for _, u := range units {
+		n := d / u.duration
+
+		if n == 0 {
+			// Only combine adjacent units, 1 week 5 minutes isn't useful.
+			if len(parts) > 0 {
+				break
+			}
+			continue
+		}
+
+		name := u.name
+		if n > 1 {
+			name += "s"
+		}
+
+		parts = append(parts, fmt.Sprintf("%d %s", n, name))
+		d -= n * u.duration
+
+		if len(parts) == 2 {
+			break
+		}
+	}
+
+	if len(parts) == 0 {
+		return "less than a minute"
+	}
+
+	return strings.Join(parts, " ")
+}
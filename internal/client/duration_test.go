@@ -0,0 +1,68 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBanDuration(t *testing.T) {
+	tests := []struct {
+		input     string
+		duration  time.Duration
+		permanent bool
+		valid     bool
+	}{
+		{"30m", 30 * time.Minute, false, true},
+		{"6h", 6 * time.Hour, false, true},
+		{"3d", 3 * 24 * time.Hour, false, true},
+		{"2w", 14 * 24 * time.Hour, false, true},
+		{"5", 5 * 24 * time.Hour, false, true},
+		{"6H\r", 6 * time.Hour, false, true},
+		{"perm", 0, true, true},
+		{"permanent", 0, true, true},
+		{"0d", 0, false, false},
+		{"52w", 52 * 7 * 24 * time.Hour, false, true},
+		{"53w", 0, false, false},
+		{"9999999999w", 0, false, false},
+		{"99999999999999m", 0, false, false},
+		{"3y", 0, false, false},
+		{"-3d", 0, false, false},
+		{"", 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			duration, permanent, err := parseBanDuration(tt.input)
+
+			if tt.valid != (err == nil) {
+				t.Fatalf("expected valid = %v; got err = %v", tt.valid, err)
+			}
+
+			if tt.duration != duration || tt.permanent != permanent {
+				t.Fatalf("expected: %v %v, got: %v %v", tt.duration, tt.permanent, duration, permanent)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		input time.Duration
+		want  string
+	}{
+		{30 * time.Second, "less than a minute"},
+		{time.Minute, "1 minute"},
+		{30 * time.Minute, "30 minutes"},
+		{6*time.Hour + 30*time.Minute, "6 hours 30 minutes"},
+		{3 * 24 * time.Hour, "3 days"},
+		{2*24*time.Hour + 23*time.Hour + 59*time.Minute, "2 days 23 hours"},
+		{8 * 24 * time.Hour, "1 week 1 day"},
+		{7*24*time.Hour + 5*time.Minute, "1 week"},
+	}
+
+	for _, tt := range tests {
+		if got := formatDuration(tt.input); got != tt.want {
+			t.Fatalf("formatDuration(%v) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package client
+
+import "github.com/nokka/d2-chatbot/internal/bnetd"
+
+// HandleEvent handles events happening on the server, announcing created games
+// and catching up subscribers logging in on what they missed.
+func (c *Client) HandleEvent(event *bnetd.Event) {
+	switch event.Type {
+	case bnetd.EventLogin:
+		// Don't hold up the event stream with mentions and digests.
+		go c.loggedIn(event.Account)
+	case bnetd.EventGameCreate:
+		c.games.Created(event.Game, event.Private, event.Time)
+	case bnetd.EventGameJoin:
+		if private, creator := c.games.Joined(event.Game, event.Time); creator {
+			c.announceGame(event.Account, event.Game, private)
+		}
+	}
+}
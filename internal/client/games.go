@@ -0,0 +1,211 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/game"
+	"github.com/nokka/d2-chatbot/internal/subscriber"
+)
+
+const (
+	// How long after a game is created its creator is expected to join it.
+	creatorTimeout = time.Minute
+
+	// Max number of game filters per account and channel.
+	maxGameFilters = 5
+
+	// Max length of a game name pattern.
+	maxPatternLength = 32
+)
+
+// createdGame is a game created that nobody joined yet.
+type createdGame struct {
+	private   bool
+	createdAt time.Time
+}
+
+// createdGames keeps track of created games until their creator joins them,
+// since PvpGN logs who created a game when they join it.
+type createdGames struct {
+	games map[string]createdGame
+	mu    sync.Mutex
+}
+
+// Created records a game being created.
+func (g *createdGames) Created(name string, private bool, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Forget games the creator never joined.
+	for n, created := range g.games {
+		if now.Sub(created.createdAt) > creatorTimeout {
+			delete(g.games, n)
+		}
+	}
+
+	g.games[strings.ToLower(name)] = createdGame{private: private, createdAt: now}
+}
+
+// Joined returns whether the game is private, and whether the account joining it is its creator.
+func (g *createdGames) Joined(name string, now time.Time) (bool, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	created, ok := g.games[strings.ToLower(name)]
+	if !ok {
+		return false, false
+	}
+
+	delete(g.games, strings.ToLower(name))
+
+	return created.private, now.Sub(created.createdAt) <= creatorTimeout
+}
+
+func newCreatedGames() *createdGames {
+	return &createdGames{
+		games: make(map[string]createdGame),
+	}
+}
+
+// announceGame whispers the created game to every subscriber with a matching game filter.
+func (c *Client) announceGame(creator string, name string, private bool) {
+	// Find who wants to hear about the game.
+	accounts := make(map[string]struct{})
+	for _, f := range c.inmem.FindGameFilters(c.chatID) {
+		if f.Matches(name) {
+			accounts[f.Account] = struct{}{}
+		}
+	}
+
+	if len(accounts) == 0 {
+		return
+	}
+
+	protected := "no"
+	if private {
+		protected = "yes"
+	}
+
+	// Announcements aren't ordered with posts, so they don't wait for the publish lock
+	// which is held across database round trips and would stall the event stream.
+	err := c.fanOut(fmt.Sprintf("[%s created game %s (password protected: %s)]", creator, name, protected), func(sub subscriber.Subscriber) bool {
+		_, ok := accounts[sub.Account]
+		return ok && sub.Account != creator && !c.inmem.IsIgnoring(sub.Account, creator, c.chatID)
+	})
+	if err != nil {
+		log.Printf("failed to announce game %s", err)
+	}
+}
+
+// Games opts the caller in or out of announcements of created games on the channel.
+func (c *Client) Games(message *Message) error {
+	usage := fmt.Sprintf("[usage: %s [on|off] [pattern]]", TypeGames)
+
+	// Only subscribers get announcements.
+	if sub := c.inmem.FindSubscriber(message.Account, c.chatID); sub == nil {
+		c.whisper(message.Account, fmt.Sprintf("[not subscribed to %s]", c.chatID))
+		return nil
+	}
+
+	parts := strings.Fields(strings.ToLower(message.Message))
+
+	// Without arguments list the filters of the caller.
+	if len(parts) == 0 {
+		patterns := c.gamePatterns(message.Account)
+		if len(patterns) == 0 {
+			c.whisper(message.Account, fmt.Sprintf("[no game announcements on %s, whisper %s on [pattern] to opt in]", c.chatID, TypeGames))
+			return nil
+		}
+
+		c.whisper(message.Account, fmt.Sprintf("[announcing games matching %s on %s]", strings.Join(patterns, ", "), c.chatID))
+		return nil
+	}
+
+	if len(parts) > 2 {
+		c.whisper(message.Account, usage)
+		return nil
+	}
+
+	pattern := ""
+	if len(parts) == 2 {
+		pattern = parts[1]
+		if len(pattern) > maxPatternLength || !game.ValidPattern(pattern) {
+			c.whisper(message.Account, usage)
+			return nil
+		}
+	}
+
+	switch parts[0] {
+	case "on":
+		if pattern == "" {
+			pattern = game.AllGames
+		}
+
+		patterns := c.gamePatterns(message.Account)
+		for _, p := range patterns {
+			if p == pattern {
+				c.whisper(message.Account, fmt.Sprintf("[already announcing games matching %s on %s]", pattern, c.chatID))
+				return nil
+			}
+		}
+
+		if len(patterns) >= maxGameFilters {
+			c.whisper(message.Account, fmt.Sprintf("[you can have at most %d game filters, remove one with %s off <pattern>]", maxGameFilters, TypeGames))
+			return nil
+		}
+
+		f := game.Filter{Account: message.Account, Chat: c.chatID, Pattern: pattern}
+
+		// Update persistent store first.
+		err := c.subscribers.AddGameFilter(f)
+		if err != nil {
+			return err
+		}
+
+		// Update persisted, update the inmem store.
+		err = c.inmem.AddGameFilter(f)
+		if err != nil {
+			return err
+		}
+
+		c.whisper(message.Account, fmt.Sprintf("[announcing games matching %s on %s]", pattern, c.chatID))
+	case "off":
+		// Update persistent store first.
+		err := c.subscribers.RemoveGameFilters(message.Account, c.chatID, pattern)
+		if err != nil {
+			return err
+		}
+
+		// Update persisted, update the inmem store.
+		err = c.inmem.RemoveGameFilters(message.Account, c.chatID, pattern)
+		if err != nil {
+			return err
+		}
+
+		if pattern == "" {
+			c.whisper(message.Account, fmt.Sprintf("[no longer announcing games on %s]", c.chatID))
+		} else {
+			c.whisper(message.Account, fmt.Sprintf("[no longer announcing games matching %s on %s]", pattern, c.chatID))
+		}
+	default:
+		c.whisper(message.Account, usage)
+	}
+
+	return nil
+}
+
+// gamePatterns returns the game filter patterns of the account on the channel.
+func (c *Client) gamePatterns(account string) []string {
+	var patterns []string
+	for _, f := range c.inmem.FindGameFilters(c.chatID) {
+		if f.Account == account {
+			patterns = append(patterns, f.Pattern)
+		}
+	}
+
+	return patterns
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCreatedGames(t *testing.T) {
+	now := time.Date(2020, 8, 28, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		create  string
+		join    string
+		after   time.Duration
+		private bool
+		creator bool
+	}{
+		{name: "creator joins", create: "baal-12", join: "baal-12", after: time.Second, creator: true},
+		{name: "name ignores case", create: "Baal-12", join: "baal-12", after: time.Second, creator: true},
+		{name: "other game", create: "baal-12", join: "cows-1", after: time.Second, creator: false},
+		{name: "joined too late", create: "baal-12", join: "baal-12", after: 2 * time.Minute, creator: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newCreatedGames()
+			g.Created(tt.create, tt.private, now)
+
+			if _, creator := g.Joined(tt.join, now.Add(tt.after)); creator != tt.creator {
+				t.Fatalf("expected creator = %v; got = %v", tt.creator, creator)
+			}
+		})
+	}
+
+	// Only the first account joining a game created it.
+	g := newCreatedGames()
+	g.Created("baal-12", true, now)
+
+	if private, creator := g.Joined("baal-12", now); !private || !creator {
+		t.Fatalf("expected private creator, got private = %v creator = %v", private, creator)
+	}
+
+	if _, creator := g.Joined("baal-12", now); creator {
+		t.Fatalf("expected second join not to be the creator")
+	}
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/role"
+)
+
+const (
+	// helpLimit is the number of times an account can ask for help per helpWindow,
+	// help takes several whispers so a single account can't fill the whisper queue with it.
+	helpLimit  = 3
+	helpWindow = 30 * time.Second
+)
+
+// usage describes an in game command in the help listing.
+type usage struct {
+	syntax      string
+	description string
+	// permission required to use the command, empty if everyone can use it.
+	permission string
+	// trade commands are only shown on channels with trade listings.
+	trade bool
+}
+
+// usages lists every command in the order they're shown by help.
+var usages = []usage{
+	{syntax: TypeSubscribe, description: "subscribe"},
+	{syntax: TypeUnsubscribe, description: "unsubscribe"},
+	{syntax: TypePublish + " <message>", description: "post a message"},
+	{syntax: TypeHelp, description: "list commands"},
+	{syntax: TypeWho + " [page]", description: "list online subscribers"},
+	{syntax: TypeSeen + " <account>", description: "when an account was last seen"},
+	{syntax: TypeGames + " [on|off] [pattern]", description: "announce created games, e.g. baal*"},
+	{syntax: TypeHistory + " [count] [page]", description: "latest messages"},
+	{syntax: TypeDigest + " [on|off]", description: "get what you missed when logging in"},
+	{syntax: TypeMentions + " [on|off]", description: "highlight messages mentioning @you"},
+	{syntax: TypeIgnore + " [account] [all]", description: "hide messages from an account here or everywhere, or list your ignores"},
+	{syntax: TypeUnignore + " <account> [all]", description: "stop ignoring an account"},
+	{syntax: TypeLFG + " <activity> [note]", description: "post looking for group"},
+	{syntax: TypeLFG + " [list] [page]", description: "list looking for group posts"},
+	{syntax: TypeLFG + " cancel", description: "cancel your looking for group post"},
+	{syntax: TypeFind + " <query>", description: "search trade listings", trade: true},
+	{syntax: TypeListings, description: "list your trade listings", trade: true},
+	{syntax: TypeUnlist + " <id|all>", description: "remove your trade listings", trade: true},
+	{syntax: TypeWatch + " [keyword]", description: "get trade posts with a keyword, or list your watches", trade: true},
+	{syntax: TypeUnwatch + " <keyword|all>", description: "remove watches", trade: true},
+	{syntax: TypeWho + " all [page]", description: "list all subscribers", permission: role.PermissionViewMembers},
+	{syntax: TypeBan + " <account> <30m|6h|3d|2w|perm> [reason]", description: "ban", permission: role.PermissionBan},
+	{syntax: TypeUnban + " <account> [reason]", description: "lift a ban", permission: role.PermissionUnban},
+	{syntax: TypeModLog + " [account]", description: "moderation history of an account or the channel", permission: role.PermissionModLog},
+	{syntax: TypeSlowMode + " [duration|off]", description: "toggle slow mode", permission: role.PermissionSlowMode},
+	{syntax: TypeAnnounce + " <message>", description: "announce to everyone", permission: role.PermissionAnnounce},
+	{syntax: TypeMod + " <account> [all]", description: "grant moderator", permission: role.PermissionManageMods},
+	{syntax: TypeUnmod + " <account> [all]", description: "revoke moderator", permission: role.PermissionManageMods},
+}
+
+// Help whispers the commands available on the channel to the caller, filtered by their privileges.
+func (c *Client) Help(message *Message) error {
+	if !c.allowed(message.Account, role.PermissionBypassLimits) {
+		if _, ok := c.helps.Allow(message.Account, time.Now()); !ok {
+			log.Printf("dropped help for %s on %s, over the help limit", message.Account, c.chatID)
+			return nil
+		}
+	}
+
+	c.whisper(message.Account, fmt.Sprintf("[commands on %s, whisper them to %s]", c.chatID, c.account))
+
+	var lines []string
+	for _, u := range usages {
+		if u.permission != "" && !c.allowed(message.Account, u.permission) {
+			continue
+		}
+
+		if u.trade && !c.tradeListings {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("[%s - %s]", u.syntax, u.description))
+	}
+
+	// Pack the usages into as few whispers as possible to spare the whisper queue.
+	for _, line := range chunk(lines, maxWhisperLength) {
+		c.whisper(message.Account, line)
+	}
+
+	return nil
+}
+
+// Hint whispers a short usage hint to an account whispering something that isn't a command,
+// at most once per helpWindow so the bot doesn't keep answering auto responders.
+func (c *Client) Hint(account string) {
+	if c.bots[account] {
+		return
+	}
+
+	if _, ok := c.hints.Allow(account, time.Now()); !ok {
+		return
+	}
+
+	c.whisper(account, fmt.Sprintf("[unknown command, whisper %s to %s for a list of commands]", TypeHelp, c.account))
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nokka/d2-chatbot/internal/inmem"
+	"github.com/nokka/d2-chatbot/internal/role"
+)
+
+func TestHelp(t *testing.T) {
+	repo := inmem.NewSubscriberRepository()
+	repo.SyncRoles([]role.Role{
+		{Account: "nokka", Chat: role.AllChats, Name: role.Admin},
+	})
+
+	tests := []struct {
+		name          string
+		account       string
+		tradeListings bool
+		usages        int
+	}{
+		{name: "subscriber", account: "meanski", usages: countUsages(false, false)},
+		{name: "subscriber on trade", account: "meanski", tradeListings: true, usages: countUsages(false, true)},
+		{name: "admin on trade", account: "nokka", tradeListings: true, usages: len(usages)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient("chat", repo)
+			c.tradeListings = tt.tradeListings
+
+			err := c.Help(&Message{Account: tt.account, Cmd: TypeHelp})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			replies := whispers(c)
+
+			var count int
+			for _, w := range replies[1:] {
+				if len(w) > maxWhisperLength {
+					t.Fatalf("expected whispers of at most %d characters, got: %d", maxWhisperLength, len(w))
+				}
+
+				count += strings.Count(w, "], [") + 1
+			}
+
+			if count != tt.usages {
+				t.Fatalf("expected %d usages, got: %d", tt.usages, count)
+			}
+
+			// Every usage used to be a whisper of its own.
+			if len(replies)-1 >= count {
+				t.Fatalf("expected usages to be packed, got %d whispers for %d usages", len(replies)-1, count)
+			}
+		})
+	}
+}
+
+func TestHelpLimit(t *testing.T) {
+	repo := inmem.NewSubscriberRepository()
+	repo.SyncRoles([]role.Role{
+		{Account: "nokka", Chat: role.AllChats, Name: role.Admin},
+	})
+
+	tests := []struct {
+		name    string
+		account string
+		replies int
+	}{
+		{name: "subscriber", account: "meanski", replies: helpLimit},
+		{name: "admin", account: "nokka", replies: helpLimit + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient("chat", repo)
+
+			for i := 0; i < helpLimit+1; i++ {
+				c.Help(&Message{Account: tt.account, Cmd: TypeHelp})
+			}
+
+			var replies int
+			for _, w := range whispers(c) {
+				if strings.HasPrefix(w, "[commands on chat") {
+					replies++
+				}
+			}
+
+			if replies != tt.replies {
+				t.Fatalf("expected %d help replies, got: %d", tt.replies, replies)
+			}
+		})
+	}
+}
+
+func TestHint(t *testing.T) {
+	repo := inmem.NewSubscriberRepository()
+
+	c := newTestClient("chat", repo)
+	c.bots["trade"] = true
+
+	c.Hint("meanski")
+	c.Hint("meanski")
+	c.Hint("nokka")
+	c.Hint("trade")
+	c.Hint("chat")
+
+	replies := whispers(c)
+	if len(replies) != 2 {
+		t.Fatalf("expected a hint to meanski and nokka, got: %q", replies)
+	}
+}
+
+// countUsages counts the usages shown with or without privileges and trade listings.
+func countUsages(privileged bool, tradeListings bool) int {
+	var n int
+	for _, u := range usages {
+		if (u.permission == "" || privileged) && (!u.trade || tradeListings) {
+			n++
+		}
+	}
+
+	return n
+}
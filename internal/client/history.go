@@ -0,0 +1,198 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/history"
+)
+
+const (
+	// historyInterval is how often messages past retention are removed.
+	historyInterval = time.Hour
+
+	// Number of messages whispered by default and at most per page.
+	defaultHistoryCount = 5
+	maxHistoryCount     = 10
+
+	// maxMessageLength is the max length of a stored message.
+	maxMessageLength = 255
+)
+
+// ring holds the latest messages published on the channel, overwriting the oldest when full.
+type ring struct {
+	mu       sync.RWMutex
+	messages []history.Message
+	next     int
+	full     bool
+}
+
+// Add adds a message, overwriting the oldest message if the ring is full.
+func (r *ring) Add(m history.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.messages) == 0 {
+		return
+	}
+
+	r.messages[r.next] = m
+	r.next = (r.next + 1) % len(r.messages)
+
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Since returns the messages published after the given time, oldest first.
+func (r *ring) Since(since time.Time) []history.Message {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ordered []history.Message
+	if r.full {
+		ordered = append(ordered, r.messages[r.next:]...)
+	}
+	ordered = append(ordered, r.messages[:r.next]...)
+
+	messages := make([]history.Message, 0, len(ordered))
+	for _, m := range ordered {
+		if m.CreatedAt.After(since) {
+			messages = append(messages, m)
+		}
+	}
+
+	return messages
+}
+
+// Size returns the max number of messages the ring holds.
+func (r *ring) Size() int {
+	return len(r.messages)
+}
+
+func newRing(size int) *ring {
+	return &ring{
+		messages: make([]history.Message, size),
+	}
+}
+
+// recordMessage stores a message published on the channel.
+func (c *Client) recordMessage(account string, text string) error {
+	m := history.Message{
+		Chat:      c.chatID,
+		Account:   account,
+		Text:      truncate(text, maxMessageLength),
+		CreatedAt: time.Now(),
+	}
+
+	c.history.Add(m)
+
+	return c.messages.AddMessage(m)
+}
+
+// History whispers a page of the latest messages on the channel to the caller, oldest first.
+func (c *Client) History(message *Message) error {
+	usage := fmt.Sprintf("[usage: %s [count] [page]]", TypeHistory)
+
+	// Only subscribers can read the channel.
+	sub := c.inmem.FindSubscriber(message.Account, c.chatID)
+	if sub == nil {
+		c.whisper(message.Account, fmt.Sprintf("[not subscribed to %s]", c.chatID))
+		return nil
+	}
+
+	if c.subscriberBanned(*sub) {
+		return nil
+	}
+
+	args := strings.Fields(message.Message)
+	if len(args) > 2 {
+		c.whisper(message.Account, usage)
+		return nil
+	}
+
+	// Count and page default to the latest few messages.
+	values := []int{defaultHistoryCount, 1}
+	for i, arg := range args {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			c.whisper(message.Account, usage)
+			return nil
+		}
+		values[i] = n
+	}
+
+	count, page := values[0], values[1]
+	if count > maxHistoryCount {
+		count = maxHistoryCount
+	}
+
+	now := time.Now()
+
+	// Leave out messages by accounts the caller ignores.
+	var messages []history.Message
+	for _, m := range c.history.Since(now.Add(-c.historyRetention)) {
+		if !c.inmem.IsIgnoring(message.Account, m.Account, c.chatID) {
+			messages = append(messages, m)
+		}
+	}
+
+	if len(messages) == 0 {
+		c.whisper(message.Account, fmt.Sprintf("[nothing was said on %s in the last %s]", c.chatID, formatDuration(c.historyRetention)))
+		return nil
+	}
+
+	pages := (len(messages) + count - 1) / count
+	if page > pages {
+		c.whisper(message.Account, fmt.Sprintf("[there are only %d pages]", pages))
+		return nil
+	}
+
+	// Pages count backwards from the latest message.
+	end := len(messages) - (page-1)*count
+	start := end - count
+	if start < 0 {
+		start = 0
+	}
+
+	c.whisper(message.Account, fmt.Sprintf("[latest messages on %s, page %d/%d]", c.chatID, page, pages))
+
+	for _, m := range messages[start:end] {
+		c.whisper(message.Account, fmt.Sprintf("[%s ago] [%s] %s", formatDuration(now.Sub(m.CreatedAt)), m.Account, m.Text))
+	}
+
+	return nil
+}
+
+// syncHistory loads the latest messages within retention into memory.
+func (c *Client) syncHistory() error {
+	messages, err := c.messages.FindMessages(c.chatID, time.Now().Add(-c.historyRetention), c.history.Size())
+	if err != nil {
+		return err
+	}
+
+	for _, m := range messages {
+		c.history.Add(m)
+	}
+
+	return nil
+}
+
+// expireHistory removes messages and queued mentions past retention every interval.
+func (c *Client) expireHistory() {
+	for range time.Tick(historyInterval) {
+		before := time.Now().Add(-c.historyRetention)
+
+		if _, err := c.messages.RemoveMessages(c.chatID, before); err != nil {
+			log.Printf("failed to remove old messages %s", err)
+		}
+
+		if _, err := c.messages.RemoveOldMentions(c.chatID, before); err != nil {
+			log.Printf("failed to remove old mentions %s", err)
+		}
+	}
+}
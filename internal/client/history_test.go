@@ -0,0 +1,47 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/history"
+)
+
+func TestRing(t *testing.T) {
+	now := time.Date(2020, 8, 28, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		size  int
+		added []string
+		since time.Duration
+		texts []string
+	}{
+		{name: "empty", size: 3, texts: []string{}},
+		{name: "not full", size: 3, added: []string{"a", "b"}, texts: []string{"a", "b"}},
+		{name: "full", size: 3, added: []string{"a", "b", "c"}, texts: []string{"a", "b", "c"}},
+		{name: "wrapped", size: 3, added: []string{"a", "b", "c", "d", "e"}, texts: []string{"c", "d", "e"}},
+		{name: "since", size: 3, added: []string{"a", "b", "c", "d"}, since: 2 * time.Minute, texts: []string{"c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRing(tt.size)
+
+			// Every message is a minute after the previous one.
+			for i, text := range tt.added {
+				r.Add(history.Message{Text: text, CreatedAt: now.Add(time.Duration(i) * time.Minute)})
+			}
+
+			texts := make([]string, 0)
+			for _, m := range r.Since(now.Add(tt.since - time.Second)) {
+				texts = append(texts, m.Text)
+			}
+
+			if !reflect.DeepEqual(tt.texts, texts) {
+				t.Fatalf("expected: %v, got: %v", tt.texts, texts)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nokka/d2-chatbot/internal/subscriber"
+)
+
+// maxIgnores is the max number of ignores an account can have.
+const maxIgnores = 50
+
+// Ignore hides the messages of an account from the caller, on this channel or all of them,
+// or lists the ignores of the caller without an account.
+func (c *Client) Ignore(message *Message) error {
+	parts := strings.Fields(message.Message)
+
+	ignores := c.inmem.FindIgnores(message.Account)
+
+	if len(parts) == 0 {
+		if len(ignores) == 0 {
+			c.whisper(message.Account, fmt.Sprintf("[you're not ignoring anyone, whisper %s <account> [all] to ignore an account]", TypeIgnore))
+			return nil
+		}
+
+		accounts := make([]string, 0, len(ignores))
+		for _, ig := range ignores {
+			if ig.Chat == subscriber.AllChats {
+				accounts = append(accounts, ig.Ignored+" (all)")
+			} else {
+				accounts = append(accounts, ig.Ignored+" ("+ig.Chat+")")
+			}
+		}
+
+		c.whisper(message.Account, fmt.Sprintf("[ignoring %d accounts]", len(accounts)))
+
+		for _, line := range chunk(accounts, maxWhisperLength) {
+			c.whisper(message.Account, line)
+		}
+
+		return nil
+	}
+
+	ig, where := c.parseIgnore(message.Account, parts)
+
+	if ig.Ignored == message.Account {
+		c.whisper(message.Account, "[you can't ignore yourself]")
+		return nil
+	}
+
+	for _, existing := range ignores {
+		if existing == ig {
+			c.whisper(message.Account, fmt.Sprintf("[already ignoring %s on %s]", ig.Ignored, where))
+			return nil
+		}
+	}
+
+	if len(ignores) >= maxIgnores {
+		c.whisper(message.Account, fmt.Sprintf("[you can ignore at most %d accounts, remove one with %s <account> [all]]", maxIgnores, TypeUnignore))
+		return nil
+	}
+
+	// Update persistent store first.
+	err := c.subscribers.AddIgnore(ig)
+	if err != nil {
+		return err
+	}
+
+	// Update persisted, update the inmem store which is shared by all bots.
+	err = c.inmem.AddIgnore(ig)
+	if err != nil {
+		return err
+	}
+
+	c.whisper(message.Account, fmt.Sprintf("[ignoring %s on %s]", ig.Ignored, where))
+
+	return nil
+}
+
+// Unignore shows the messages of an ignored account to the caller again, on this channel or all of them.
+func (c *Client) Unignore(message *Message) error {
+	parts := strings.Fields(message.Message)
+	if len(parts) == 0 {
+		c.whisper(message.Account, fmt.Sprintf("[usage: %s <account> [all]]", TypeUnignore))
+		return nil
+	}
+
+	ig, where := c.parseIgnore(message.Account, parts)
+
+	var exists bool
+	for _, existing := range c.inmem.FindIgnores(message.Account) {
+		if existing == ig {
+			exists = true
+			break
+		}
+	}
+
+	if !exists {
+		c.whisper(message.Account, fmt.Sprintf("[you're not ignoring %s on %s]", ig.Ignored, where))
+		return nil
+	}
+
+	// Update persistent store first.
+	err := c.subscribers.RemoveIgnore(ig)
+	if err != nil {
+		return err
+	}
+
+	// Update persisted, update the inmem store which is shared by all bots.
+	err = c.inmem.RemoveIgnore(ig)
+	if err != nil {
+		return err
+	}
+
+	c.whisper(message.Account, fmt.Sprintf("[no longer ignoring %s on %s]", ig.Ignored, where))
+
+	if c.inmem.IsIgnoring(message.Account, ig.Ignored, c.chatID) {
+		c.whisper(message.Account, fmt.Sprintf("[still ignoring %s on all channels, whisper %s %s all to stop]", ig.Ignored, TypeUnignore, ig.Ignored))
+	}
+
+	return nil
+}
+
+// parseIgnore parses "<account> [all]" into an ignore of the account and where it applies.
+func (c *Client) parseIgnore(account string, parts []string) (subscriber.Ignore, string) {
+	ig := subscriber.Ignore{
+		Account: account,
+		Chat:    c.chatID,
+		Ignored: strings.ToLower(parts[0]),
+	}
+
+	where := c.chatID
+	if len(parts) > 1 && strings.ToLower(parts[1]) == "all" {
+		ig.Chat = subscriber.AllChats
+		where = "all channels"
+	}
+
+	return ig, where
+}
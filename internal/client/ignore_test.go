@@ -0,0 +1,86 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/history"
+	"github.com/nokka/d2-chatbot/internal/inmem"
+	"github.com/nokka/d2-chatbot/internal/subscriber"
+)
+
+func TestIgnore(t *testing.T) {
+	repo := inmem.NewSubscriberRepository()
+	chat := newTestClient("chat", repo)
+	trade := newTestClient("trade", repo)
+
+	tests := []struct {
+		name    string
+		client  *Client
+		cmd     string
+		message string
+		reply   string
+		ignores int
+	}{
+		{name: "list none", client: chat, cmd: TypeIgnore, reply: "[you're not ignoring anyone, whisper ignore <account> [all] to ignore an account]"},
+		{name: "ignore self", client: chat, cmd: TypeIgnore, message: "Nokka", reply: "[you can't ignore yourself]"},
+		{name: "ignore on chat", client: chat, cmd: TypeIgnore, message: "Meanski", reply: "[ignoring meanski on chat]", ignores: 1},
+		{name: "ignore twice", client: chat, cmd: TypeIgnore, message: "meanski", reply: "[already ignoring meanski on chat]", ignores: 1},
+		{name: "ignore everywhere", client: chat, cmd: TypeIgnore, message: "bruse all", reply: "[ignoring bruse on all channels]", ignores: 2},
+		{name: "list", client: chat, cmd: TypeIgnore, reply: "[ignoring 2 accounts]", ignores: 2},
+		{name: "unignore usage", client: chat, cmd: TypeUnignore, reply: "[usage: unignore <account> [all]]", ignores: 2},
+		{name: "unignore on other chat", client: trade, cmd: TypeUnignore, message: "meanski", reply: "[you're not ignoring meanski on trade]", ignores: 2},
+		{name: "unignore on chat", client: chat, cmd: TypeUnignore, message: "meanski", reply: "[no longer ignoring meanski on chat]", ignores: 1},
+		{name: "unignore everywhere", client: trade, cmd: TypeUnignore, message: "bruse all", reply: "[no longer ignoring bruse on all channels]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := &Message{Account: "nokka", Cmd: tt.cmd, Message: tt.message}
+
+			var err error
+			if tt.cmd == TypeIgnore {
+				err = tt.client.Ignore(message)
+			} else {
+				err = tt.client.Unignore(message)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			replies := whispers(tt.client)
+			if len(replies) == 0 || replies[0] != tt.reply {
+				t.Fatalf("expected reply: %q, got: %q", tt.reply, replies)
+			}
+
+			if ignores := len(repo.FindIgnores("nokka")); ignores != tt.ignores {
+				t.Fatalf("expected %d ignores, got: %d", tt.ignores, ignores)
+			}
+		})
+	}
+}
+
+func TestHistoryIgnores(t *testing.T) {
+	repo := inmem.NewSubscriberRepository()
+	repo.SyncSubscribers("chat", []subscriber.Subscriber{{Account: "nokka", Online: true}})
+	repo.SyncIgnores([]subscriber.Ignore{{Account: "nokka", Chat: subscriber.AllChats, Ignored: "meanski"}})
+
+	c := newTestClient("chat", repo)
+	c.history = newRing(10)
+	c.historyRetention = time.Hour
+
+	now := time.Now()
+	c.history.Add(history.Message{Chat: "chat", Account: "meanski", Text: "hello", CreatedAt: now})
+	c.history.Add(history.Message{Chat: "chat", Account: "bruse", Text: "hi", CreatedAt: now})
+
+	err := c.History(&Message{Account: "nokka", Cmd: TypeHistory})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	replies := whispers(c)
+	if len(replies) != 2 || !strings.HasSuffix(replies[1], "[bruse] hi") {
+		t.Fatalf("expected only the message by bruse, got: %q", replies)
+	}
+}
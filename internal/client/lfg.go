@@ -0,0 +1,202 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// lfgInterval is how often expired looking for group posts are removed.
+	lfgInterval = 30 * time.Second
+
+	// lfgPageSize is the number of posts listed per page.
+	lfgPageSize = 5
+
+	// Max length of the activity and note of a post.
+	maxActivityLength = 16
+	maxNoteLength     = 120
+)
+
+// lfgPost is a looking for group post on the board.
+type lfgPost struct {
+	account   string
+	activity  string
+	note      string
+	createdAt time.Time
+	expiresAt time.Time
+}
+
+// lfgBoard holds the open looking for group posts of a channel, one per account.
+type lfgBoard struct {
+	mu    sync.Mutex
+	posts map[string]lfgPost
+}
+
+// Post puts the post on the board, replacing any earlier post of the account.
+func (b *lfgBoard) Post(post lfgPost) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.posts[post.account] = post
+}
+
+// Cancel removes the post of the account, reporting whether it had one.
+func (b *lfgBoard) Cancel(account string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.posts[account]; !ok {
+		return false
+	}
+
+	delete(b.posts, account)
+
+	return true
+}
+
+// List returns the open posts, oldest first.
+func (b *lfgBoard) List(now time.Time) []lfgPost {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	posts := make([]lfgPost, 0, len(b.posts))
+	for _, post := range b.posts {
+		if now.Before(post.expiresAt) {
+			posts = append(posts, post)
+		}
+	}
+
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].createdAt.Before(posts[j].createdAt)
+	})
+
+	return posts
+}
+
+// Expire removes and returns the posts that have expired.
+func (b *lfgBoard) Expire(now time.Time) []lfgPost {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var expired []lfgPost
+	for account, post := range b.posts {
+		if !now.Before(post.expiresAt) {
+			expired = append(expired, post)
+			delete(b.posts, account)
+		}
+	}
+
+	return expired
+}
+
+func newLFGBoard() *lfgBoard {
+	return &lfgBoard{
+		posts: make(map[string]lfgPost),
+	}
+}
+
+// LFG posts, lists or cancels looking for group posts on the channel.
+func (c *Client) LFG(message *Message) error {
+	args := strings.Fields(message.Message)
+
+	if len(args) == 0 {
+		return c.listLFG(message.Account, 1)
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "list":
+		page := 1
+		if len(args) > 1 {
+			p, err := strconv.Atoi(args[1])
+			if err != nil || p < 1 {
+				c.whisper(message.Account, fmt.Sprintf("[usage: %s list [page]]", TypeLFG))
+				return nil
+			}
+			page = p
+		}
+
+		return c.listLFG(message.Account, page)
+	case "cancel":
+		if !c.lfg.Cancel(message.Account) {
+			c.whisper(message.Account, fmt.Sprintf("[you have no lfg post on %s]", c.chatID))
+			return nil
+		}
+
+		c.whisper(message.Account, fmt.Sprintf("[your lfg post on %s was cancelled]", c.chatID))
+		return nil
+	}
+
+	// Banned subscribers can't post.
+	if sub := c.inmem.FindSubscriber(message.Account, c.chatID); sub != nil && c.subscriberBanned(*sub) {
+		return nil
+	}
+
+	activity := strings.ToLower(args[0])
+	note := strings.Join(args[1:], " ")
+
+	if len(activity) > maxActivityLength || len(note) > maxNoteLength {
+		c.whisper(message.Account, fmt.Sprintf("[activity can be at most %d characters and note %d]", maxActivityLength, maxNoteLength))
+		return nil
+	}
+
+	now := time.Now()
+	c.lfg.Post(lfgPost{
+		account:   message.Account,
+		activity:  activity,
+		note:      note,
+		createdAt: now,
+		expiresAt: now.Add(c.lfgExpiry),
+	})
+
+	c.whisper(message.Account, fmt.Sprintf("[posted %s on %s for %s, whisper %s cancel to remove it]", activity, c.chatID, formatDuration(c.lfgExpiry), TypeLFG))
+
+	return nil
+}
+
+// listLFG whispers a page of open looking for group posts to the account.
+func (c *Client) listLFG(account string, page int) error {
+	now := time.Now()
+	posts := c.lfg.List(now)
+
+	if len(posts) == 0 {
+		c.whisper(account, fmt.Sprintf("[no lfg posts on %s, whisper %s <activity> [note] to post one]", c.chatID, TypeLFG))
+		return nil
+	}
+
+	pages := (len(posts) + lfgPageSize - 1) / lfgPageSize
+	if page > pages {
+		c.whisper(account, fmt.Sprintf("[there are only %d pages]", pages))
+		return nil
+	}
+
+	c.whisper(account, fmt.Sprintf("[%d lfg posts on %s, page %d/%d]", len(posts), c.chatID, page, pages))
+
+	start := (page - 1) * lfgPageSize
+	end := start + lfgPageSize
+	if end > len(posts) {
+		end = len(posts)
+	}
+
+	for _, post := range posts[start:end] {
+		line := fmt.Sprintf("%s: %s", post.account, post.activity)
+		if post.note != "" {
+			line += " - " + post.note
+		}
+
+		c.whisper(account, fmt.Sprintf("[%s (%s left)]", line, formatDuration(post.expiresAt.Sub(now))))
+	}
+
+	return nil
+}
+
+// expireLFG removes expired looking for group posts every interval, letting their posters know.
+func (c *Client) expireLFG() {
+	for range time.Tick(lfgInterval) {
+		for _, post := range c.lfg.Expire(time.Now()) {
+			c.whisper(post.account, fmt.Sprintf("[your lfg post %s on %s expired]", post.activity, c.chatID))
+		}
+	}
+}
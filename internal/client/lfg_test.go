@@ -0,0 +1,53 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLFGBoard(t *testing.T) {
+	now := time.Date(2020, 8, 28, 12, 0, 0, 0, time.UTC)
+
+	b := newLFGBoard()
+	b.Post(lfgPost{account: "nokka", activity: "baal", createdAt: now, expiresAt: now.Add(30 * time.Minute)})
+	b.Post(lfgPost{account: "meanski", activity: "cows", createdAt: now.Add(time.Minute), expiresAt: now.Add(10 * time.Minute)})
+	b.Post(lfgPost{account: "bruse", activity: "trist", createdAt: now.Add(2 * time.Minute), expiresAt: now.Add(20 * time.Minute)})
+
+	accounts := func(posts []lfgPost) []string {
+		var names []string
+		for _, post := range posts {
+			names = append(names, post.account)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name    string
+		at      time.Duration
+		cancel  string
+		listed  []string
+		expired []string
+	}{
+		{name: "all open", at: 5 * time.Minute, listed: []string{"nokka", "meanski", "bruse"}},
+		{name: "one expired", at: 15 * time.Minute, listed: []string{"nokka", "bruse"}, expired: []string{"meanski"}},
+		{name: "cancelled", at: 16 * time.Minute, cancel: "bruse", listed: []string{"nokka"}},
+		{name: "all expired", at: time.Hour, expired: []string{"nokka"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.cancel != "" && !b.Cancel(tt.cancel) {
+				t.Fatalf("expected %s to have a post to cancel", tt.cancel)
+			}
+
+			if listed := accounts(b.List(now.Add(tt.at))); !reflect.DeepEqual(tt.listed, listed) {
+				t.Fatalf("expected: %v, got: %v", tt.listed, listed)
+			}
+
+			if expired := accounts(b.Expire(now.Add(tt.at))); !reflect.DeepEqual(tt.expired, expired) {
+				t.Fatalf("expected: %v, got: %v", tt.expired, expired)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nokka/d2-chatbot/internal/history"
+)
+
+// colorGold is the D2 color code "ÿc4", coloring the text after it gold. The game
+// expects the ÿ as the single byte 0xff rather than its UTF-8 encoding.
+const colorGold = "\xffc4"
+
+// maxQueuedMentions is the max number of mentions delivered on login, the latest are kept.
+const maxQueuedMentions = 10
+
+// Mention of an account in a message, such as "@nokka".
+var mentionRegex = regexp.MustCompile(`(?i)(?:^|[^a-z0-9_\-])@([a-z0-9_\-]+)`)
+
+// mentions returns the accounts mentioned in the text, in order and without duplicates.
+func mentions(text string) []string {
+	var (
+		accounts []string
+		seen     = make(map[string]struct{})
+	)
+
+	for _, m := range mentionRegex.FindAllStringSubmatch(text, -1) {
+		account := strings.ToLower(m[1])
+		if _, ok := seen[account]; ok {
+			continue
+		}
+
+		seen[account] = struct{}{}
+		accounts = append(accounts, account)
+	}
+
+	return accounts
+}
+
+// mentioned reports whether the account is mentioned in the text.
+func mentioned(text string, account string) bool {
+	for _, m := range mentions(text) {
+		if m == account {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notifyMentions whispers a highlighted message to the online subscribers mentioned in it,
+// and queues it for offline ones. Mentions of the sender, accounts that aren't subscribed,
+// banned subscribers and subscribers that turned mentions off are ignored. It returns the
+// accounts that got the highlighted whisper.
+func (c *Client) notifyMentions(sender string, message string) map[string]struct{} {
+	notified := make(map[string]struct{})
+	text := postText(message)
+	now := time.Now()
+
+	for _, account := range mentions(text) {
+		if account == sender {
+			continue
+		}
+
+		sub := c.inmem.FindSubscriber(account, c.chatID)
+		if sub == nil || sub.Banned(now) || sub.MentionsDisabled {
+			continue
+		}
+
+		// Ignored senders can't reach the account by mentioning it either.
+		if c.inmem.IsIgnoring(account, sender, c.chatID) {
+			continue
+		}
+
+		if sub.Online {
+			c.whisper(account, colorGold+message)
+			notified[account] = struct{}{}
+			continue
+		}
+
+		err := c.messages.AddMention(history.Mention{
+			Account:   account,
+			Chat:      c.chatID,
+			Sender:    sender,
+			Text:      truncate(text, maxMessageLength),
+			CreatedAt: now,
+		})
+		if err != nil {
+			log.Printf("failed to queue mention %s", err)
+		}
+	}
+
+	return notified
+}
+
+// deliverMentions whispers the mentions queued while the account was offline.
+func (c *Client) deliverMentions(account string) error {
+	queued, err := c.messages.FindMentions(account, c.chatID, maxQueuedMentions)
+	if err != nil {
+		return err
+	}
+
+	if len(queued) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	c.whisper(account, fmt.Sprintf("[you were mentioned on %s while offline]", c.chatID))
+
+	for _, m := range queued {
+		c.whisper(account, fmt.Sprintf("%s[%s ago] [%s] %s", colorGold, formatDuration(now.Sub(m.CreatedAt)), m.Sender, m.Text))
+	}
+
+	return c.messages.RemoveMentions(account, c.chatID)
+}
+
+// Mentions turns highlighted whispers of messages mentioning the caller on or off.
+func (c *Client) Mentions(message *Message) error {
+	sub := c.inmem.FindSubscriber(message.Account, c.chatID)
+	if sub == nil {
+		c.whisper(message.Account, fmt.Sprintf("[not subscribed to %s]", c.chatID))
+		return nil
+	}
+
+	var enabled bool
+	switch strings.ToLower(message.Message) {
+	case "":
+		if sub.MentionsDisabled {
+			c.whisper(message.Account, fmt.Sprintf("[mentions are off for %s, whisper %s on to highlight messages mentioning @%s]", c.chatID, TypeMentions, message.Account))
+		} else {
+			c.whisper(message.Account, fmt.Sprintf("[mentions are on for %s, whisper %s off to turn them off]", c.chatID, TypeMentions))
+		}
+		return nil
+	case "on":
+		enabled = true
+	case "off":
+		enabled = false
+	default:
+		c.whisper(message.Account, fmt.Sprintf("[usage: %s [on|off]]", TypeMentions))
+		return nil
+	}
+
+	// Update persistent store first.
+	err := c.subscribers.UpdateMentions(message.Account, c.chatID, enabled)
+	if err != nil {
+		return err
+	}
+
+	// Update persisted, update the inmem store.
+	err = c.inmem.UpdateMentions(message.Account, c.chatID, enabled)
+	if err != nil {
+		return err
+	}
+
+	if enabled {
+		c.whisper(message.Account, fmt.Sprintf("[mentions turned on for %s]", c.chatID))
+	} else {
+		c.whisper(message.Account, fmt.Sprintf("[mentions turned off for %s]", c.chatID))
+	}
+
+	return nil
+}
+
+// truncate cuts the text to at most max bytes, without splitting a character.
+func truncate(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+
+	for max > 0 && !utf8.RuneStart(text[max]) {
+		max--
+	}
+
+	return text[:max]
+}
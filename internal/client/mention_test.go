@@ -0,0 +1,50 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		accounts []string
+	}{
+		{name: "single", input: "@nokka you there?", accounts: []string{"nokka"}},
+		{name: "several", input: "hey @Nokka and @meanski, baal?", accounts: []string{"nokka", "meanski"}},
+		{name: "duplicate", input: "@nokka @NOKKA", accounts: []string{"nokka"}},
+		{name: "email", input: "mail me at me@nokka.com", accounts: nil},
+		{name: "none", input: "wts shako", accounts: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if accounts := mentions(tt.input); !reflect.DeepEqual(tt.accounts, accounts) {
+				t.Fatalf("expected: %v, got: %v", tt.accounts, accounts)
+			}
+		})
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		max    int
+		output string
+	}{
+		{name: "short", input: "hello", max: 10, output: "hello"},
+		{name: "long", input: "hello team", max: 5, output: "hello"},
+		{name: "split character", input: "héllo", max: 2, output: "h"},
+		{name: "whole character", input: "héllo", max: 3, output: "hé"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if output := truncate(tt.input, tt.max); output != tt.output {
+				t.Fatalf("expected: %q, got: %q", tt.output, output)
+			}
+		})
+	}
+}
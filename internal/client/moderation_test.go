@@ -0,0 +1,113 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/inmem"
+	"github.com/nokka/d2-chatbot/internal/moderation"
+	"github.com/nokka/d2-chatbot/internal/role"
+)
+
+// fakeModeration keeps logged actions in memory and records the chat actions were looked up on.
+type fakeModeration struct {
+	actions []moderation.Action
+	chatID  string
+}
+
+func (m *fakeModeration) LogAction(action moderation.Action) error {
+	m.actions = append(m.actions, action)
+	return nil
+}
+
+func (m *fakeModeration) FindActions(target string, chatID string, limit int) ([]moderation.Action, error) {
+	m.chatID = chatID
+
+	var actions []moderation.Action
+	for _, a := range m.actions {
+		if a.Target == target && (chatID == "" || a.Chat == chatID || a.Chat == role.AllChats) {
+			actions = append(actions, a)
+		}
+	}
+
+	return actions, nil
+}
+
+func newModerationClient(repo *inmem.SubscriberRepository, m *fakeModeration) *Client {
+	c := newTestClient("chat", repo)
+	c.moderation = m
+	c.limiter = newLimiter(5, 0)
+	c.slowMode = 30 * time.Second
+
+	return c
+}
+
+func TestModerationActions(t *testing.T) {
+	repo := inmem.NewSubscriberRepository()
+	repo.SyncSubscribers("chat", nil)
+	repo.SyncRoles([]role.Role{
+		{Account: "nokka", Chat: "chat", Name: role.Admin},
+		{Account: "meanski", Chat: role.AllChats, Name: role.Moderator},
+	})
+
+	m := &fakeModeration{}
+	c := newModerationClient(repo, m)
+
+	if err := c.SlowMode(&Message{Account: "nokka", Cmd: TypeSlowMode}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := c.SlowMode(&Message{Account: "nokka", Cmd: TypeSlowMode, Message: "off"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := c.Announce(&Message{Account: "nokka", Cmd: TypeAnnounce, Message: "ladder reset"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []moderation.Action{
+		{Actor: "nokka", Chat: "chat", Type: moderation.TypeSlowMode, Reason: "30s"},
+		{Actor: "nokka", Chat: "chat", Type: moderation.TypeSlowMode, Reason: "off"},
+		{Actor: "nokka", Chat: "chat", Type: moderation.TypeAnnounce, Reason: "ladder reset"},
+	}
+
+	if len(m.actions) != len(want) {
+		t.Fatalf("expected %d actions, got: %d", len(want), len(m.actions))
+	}
+
+	for i, a := range m.actions {
+		a.CreatedAt = want[i].CreatedAt
+		if a != want[i] {
+			t.Fatalf("expected: %+v, got: %+v", want[i], a)
+		}
+	}
+
+	// Moderators of a single channel only see actions on it.
+	if err := c.ModLog(&Message{Account: "nokka", Cmd: TypeModLog, Message: "trog"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m.chatID != "chat" {
+		t.Fatalf("expected actions on chat, got: %q", m.chatID)
+	}
+
+	if err := c.ModLog(&Message{Account: "meanski", Cmd: TypeModLog, Message: "trog"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if m.chatID != "" {
+		t.Fatalf("expected actions on every chat, got: %q", m.chatID)
+	}
+
+	// Without an account the actions on the whole channel are listed.
+	whispers(c)
+	if err := c.ModLog(&Message{Account: "nokka", Cmd: TypeModLog}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	replies := whispers(c)
+	if len(replies) != 3 || !strings.HasSuffix(replies[0], "slowmode on chat by nokka, reason: 30s]") {
+		t.Fatalf("expected the channel actions, got: %q", replies)
+	}
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueFull is returned when a whisper is dropped because the queue is full.
+var ErrQueueFull = errors.New("whisper queue is full")
+
+// disconnectedPause is how long the queue waits before retrying a whisper while the client is disconnected.
+const disconnectedPause = 500 * time.Millisecond
+
+// QueueStats holds the counters of an outbound whisper queue.
+type QueueStats struct {
+	Queued  uint64
+	Sent    uint64
+	Failed  uint64
+	Dropped uint64
+	Depth   int
+}
+
+// outbound is a whisper waiting to be sent.
+type outbound struct {
+	account string
+	message string
+}
+
+// queue is an outbound whisper queue, throttled by a token bucket to
+// stay below the flood protection of the PvpGN server.
+type queue struct {
+	send   func(account string, message string) error
+	items  chan outbound
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	queued  uint64
+	sent    uint64
+	failed  uint64
+	dropped uint64
+}
+
+// Push adds a whisper to the queue, it's dropped if the queue is full.
+func (q *queue) Push(account string, message string) error {
+	select {
+	case q.items <- outbound{account: account, message: message}:
+		atomic.AddUint64(&q.queued, 1)
+		return nil
+	default:
+		atomic.AddUint64(&q.dropped, 1)
+		return ErrQueueFull
+	}
+}
+
+// Stats returns the current counters of the queue.
+func (q *queue) Stats() QueueStats {
+	return QueueStats{
+		Queued:  atomic.LoadUint64(&q.queued),
+		Sent:    atomic.LoadUint64(&q.sent),
+		Failed:  atomic.LoadUint64(&q.failed),
+		Dropped: atomic.LoadUint64(&q.dropped),
+		Depth:   len(q.items),
+	}
+}
+
+// run sends queued whispers in order as fast as the rate allows. While the
+// client is disconnected draining is paused, so whispers aren't lost on reconnects.
+func (q *queue) run() {
+	for w := range q.items {
+		q.wait()
+
+		err := q.send(w.account, w.message)
+		for err == ErrNotConnected {
+			time.Sleep(disconnectedPause)
+			err = q.send(w.account, w.message)
+		}
+
+		// If there's an error, log it and continue with the next whisper.
+		if err != nil {
+			atomic.AddUint64(&q.failed, 1)
+			log.Printf("failed to whisper %s: %s", w.account, err)
+			continue
+		}
+
+		atomic.AddUint64(&q.sent, 1)
+	}
+}
+
+// wait blocks until there's a token available in the bucket and takes it.
+func (q *queue) wait() {
+	now := time.Now()
+
+	// Refill the bucket with the tokens earned since the last send.
+	q.tokens += now.Sub(q.last).Seconds() * q.rate
+	if q.tokens > q.burst {
+		q.tokens = q.burst
+	}
+	q.last = now
+
+	if q.tokens < 1 {
+		time.Sleep(time.Duration((1 - q.tokens) / q.rate * float64(time.Second)))
+		q.tokens = 1
+		q.last = time.Now()
+	}
+
+	q.tokens--
+}
+
+// newQueue returns a queue sending whispers with the given function at
+// rate whispers per second, allowing bursts and holding at most size whispers.
+func newQueue(send func(account string, message string) error, rate float64, burst int, size int) *queue {
+	return &queue{
+		send:   send,
+		items:  make(chan outbound, size),
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder records the time of every whisper sent through a queue.
+type recorder struct {
+	mu        sync.Mutex
+	connected bool
+	sent      []time.Time
+}
+
+func (r *recorder) send(account string, message string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.connected {
+		return ErrNotConnected
+	}
+
+	r.sent = append(r.sent, time.Now())
+	return nil
+}
+
+func (r *recorder) setConnected(connected bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.connected = connected
+}
+
+func (r *recorder) times() []time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]time.Time(nil), r.sent...)
+}
+
+// waitSent waits until n whispers are sent or the timeout passes.
+func (r *recorder) waitSent(n int, timeout time.Duration) []time.Time {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if sent := r.times(); len(sent) >= n {
+			return sent
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	return r.times()
+}
+
+func TestQueueRate(t *testing.T) {
+	r := &recorder{connected: true}
+
+	// 20 whispers per second is one every 50ms, after a burst of 2.
+	q := newQueue(r.send, 20, 2, 10)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := q.Push("nokka", "hello"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	go q.run()
+
+	sent := r.waitSent(4, 2*time.Second)
+	if len(sent) != 4 {
+		t.Fatalf("expected 4 whispers sent, got: %d", len(sent))
+	}
+
+	// The burst goes out right away.
+	if d := sent[1].Sub(start); d > 40*time.Millisecond {
+		t.Fatalf("expected the burst to be sent at once, took: %v", d)
+	}
+
+	// The rest is throttled by the rate.
+	if d := sent[3].Sub(sent[1]); d < 90*time.Millisecond {
+		t.Fatalf("expected whispers after the burst to be throttled, took: %v", d)
+	}
+
+	if stats := q.Stats(); stats.Queued != 4 || stats.Depth != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	r := &recorder{connected: true}
+	q := newQueue(r.send, 1, 1, 2)
+
+	for i := 0; i < 2; i++ {
+		if err := q.Push("nokka", "hello"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := q.Push("nokka", "hello"); err != ErrQueueFull {
+		t.Fatalf("expected: %v, got: %v", ErrQueueFull, err)
+	}
+
+	if stats := q.Stats(); stats.Queued != 2 || stats.Dropped != 1 || stats.Depth != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestQueueDisconnected(t *testing.T) {
+	r := &recorder{}
+	q := newQueue(r.send, 100, 5, 10)
+
+	q.Push("nokka", "hello")
+	q.Push("meanski", "hello")
+
+	go q.run()
+
+	// Nothing is sent, or lost, while disconnected.
+	time.Sleep(100 * time.Millisecond)
+	if sent := r.times(); len(sent) != 0 {
+		t.Fatalf("expected no whispers sent while disconnected, got: %d", len(sent))
+	}
+
+	r.setConnected(true)
+
+	if sent := r.waitSent(2, 2*time.Second); len(sent) != 2 {
+		t.Fatalf("expected 2 whispers sent after reconnecting, got: %d", len(sent))
+	}
+
+	if stats := q.Stats(); stats.Failed != 0 {
+		t.Fatalf("expected no failed whispers, got: %d", stats.Failed)
+	}
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter limits how often accounts can post on a channel, both by a
+// number of posts per window and by the slow mode interval if enabled.
+type limiter struct {
+	mu       sync.Mutex
+	limit    int
+	window   time.Duration
+	slowMode time.Duration
+	posts    map[string][]time.Time
+	swept    time.Time
+}
+
+// Allow records a post by the account if it's allowed, otherwise it
+// returns how long the account has to wait before it can post again.
+func (l *limiter) Allow(account string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Forget posts that are too old to affect any of the limits.
+	var keep time.Duration
+	if l.limit > 0 {
+		keep = l.window
+	}
+	if l.slowMode > keep {
+		keep = l.slowMode
+	}
+
+	// Nothing is limited, there's no need to remember the post.
+	if keep == 0 {
+		return 0, true
+	}
+
+	// Forget accounts that haven't posted for a while, they'd otherwise be kept forever.
+	if now.Sub(l.swept) >= keep {
+		l.sweep(now, keep)
+	}
+
+	var posts []time.Time
+	for _, t := range l.posts[account] {
+		if now.Sub(t) < keep {
+			posts = append(posts, t)
+		}
+	}
+
+	var wait time.Duration
+
+	// Slow mode only allows one post per interval.
+	if l.slowMode > 0 && len(posts) > 0 {
+		if w := posts[len(posts)-1].Add(l.slowMode).Sub(now); w > wait {
+			wait = w
+		}
+	}
+
+	// Count the posts within the window, the oldest one decides when the next post is allowed.
+	if l.limit > 0 {
+		var inWindow []time.Time
+		for _, t := range posts {
+			if now.Sub(t) < l.window {
+				inWindow = append(inWindow, t)
+			}
+		}
+
+		if len(inWindow) >= l.limit {
+			if w := inWindow[len(inWindow)-l.limit].Add(l.window).Sub(now); w > wait {
+				wait = w
+			}
+		}
+	}
+
+	if wait > 0 {
+		l.posts[account] = posts
+		return wait, false
+	}
+
+	l.posts[account] = append(posts, now)
+
+	return 0, true
+}
+
+// sweep removes the accounts whose latest post is older than keep.
+func (l *limiter) sweep(now time.Time, keep time.Duration) {
+	for account, posts := range l.posts {
+		if len(posts) == 0 || now.Sub(posts[len(posts)-1]) >= keep {
+			delete(l.posts, account)
+		}
+	}
+
+	l.swept = now
+}
+
+// SetSlowMode sets the slow mode interval, 0 disables slow mode.
+func (l *limiter) SetSlowMode(interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.slowMode = interval
+}
+
+// SlowMode returns the current slow mode interval, 0 means disabled.
+func (l *limiter) SlowMode() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.slowMode
+}
+
+// newLimiter returns a limiter allowing limit posts per window for every account.
+func newLimiter(limit int, window time.Duration) *limiter {
+	return &limiter{
+		limit:  limit,
+		window: window,
+		posts:  make(map[string][]time.Time),
+	}
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	start := time.Date(2020, 8, 28, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		limit    int
+		window   time.Duration
+		slowMode time.Duration
+		posts    []time.Duration
+		at       time.Duration
+		wait     time.Duration
+		allowed  bool
+	}{
+		{
+			name:    "first post",
+			limit:   2,
+			window:  time.Minute,
+			at:      0,
+			allowed: true,
+		},
+		{
+			name:    "below limit",
+			limit:   2,
+			window:  time.Minute,
+			posts:   []time.Duration{0},
+			at:      time.Second,
+			allowed: true,
+		},
+		{
+			name:    "limit reached",
+			limit:   2,
+			window:  time.Minute,
+			posts:   []time.Duration{0, 10 * time.Second},
+			at:      20 * time.Second,
+			wait:    40 * time.Second,
+			allowed: false,
+		},
+		{
+			name:    "window passed",
+			limit:   2,
+			window:  time.Minute,
+			posts:   []time.Duration{0, 10 * time.Second},
+			at:      time.Minute,
+			allowed: true,
+		},
+		{
+			name:     "slow mode",
+			limit:    5,
+			window:   time.Minute,
+			slowMode: 30 * time.Second,
+			posts:    []time.Duration{0},
+			at:       10 * time.Second,
+			wait:     20 * time.Second,
+			allowed:  false,
+		},
+		{
+			name:     "slow mode passed",
+			limit:    5,
+			window:   time.Minute,
+			slowMode: 30 * time.Second,
+			posts:    []time.Duration{0},
+			at:       30 * time.Second,
+			allowed:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLimiter(tt.limit, tt.window)
+			l.SetSlowMode(tt.slowMode)
+
+			for _, p := range tt.posts {
+				l.posts["nokka"] = append(l.posts["nokka"], start.Add(p))
+			}
+
+			wait, allowed := l.Allow("nokka", start.Add(tt.at))
+
+			if tt.allowed != allowed {
+				t.Fatalf("expected allowed = %v; got = %v", tt.allowed, allowed)
+			}
+
+			if tt.wait != wait {
+				t.Fatalf("expected wait = %v; got = %v", tt.wait, wait)
+			}
+		})
+	}
+}
+
+func TestLimiterSweep(t *testing.T) {
+	start := time.Date(2020, 8, 28, 8, 0, 0, 0, time.UTC)
+
+	l := newLimiter(2, time.Minute)
+
+	l.Allow("nokka", start)
+	l.Allow("meanski", start.Add(30*time.Second))
+
+	if len(l.posts) != 2 {
+		t.Fatalf("expected 2 accounts; got = %d", len(l.posts))
+	}
+
+	// nokka's post has left the window, meanski's hasn't.
+	l.Allow("bruse", start.Add(time.Minute+10*time.Second))
+
+	if _, ok := l.posts["nokka"]; ok {
+		t.Fatalf("expected nokka to be swept")
+	}
+
+	if _, ok := l.posts["meanski"]; !ok {
+		t.Fatalf("expected meanski to be kept")
+	}
+
+	// Without any limits nothing is remembered.
+	l = newLimiter(0, time.Minute)
+	l.Allow("nokka", start)
+
+	if len(l.posts) != 0 {
+		t.Fatalf("expected no accounts; got = %d", len(l.posts))
+	}
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Seen whispers when the given account was last seen on the server.
+func (c *Client) Seen(message *Message) error {
+	parts := strings.Fields(message.Message)
+	if len(parts) < 1 {
+		c.whisper(message.Account, fmt.Sprintf("[usage: %s <account>]", TypeSeen))
+		return nil
+	}
+
+	account := strings.ToLower(parts[0])
+
+	p, err := c.presence.FindPresence(account)
+	if err != nil {
+		return err
+	}
+
+	if p == nil {
+		c.whisper(message.Account, fmt.Sprintf("[%s has never been seen]", account))
+		return nil
+	}
+
+	if p.Online() {
+		c.whisper(message.Account, fmt.Sprintf("[%s is online, logged in %s ago]", account, formatDuration(time.Since(*p.LastLogin))))
+		return nil
+	}
+
+	if p.LastLogout == nil {
+		c.whisper(message.Account, fmt.Sprintf("[%s has never been seen]", account))
+		return nil
+	}
+
+	c.whisper(message.Account, fmt.Sprintf("[%s was last seen %s ago]", account, formatDuration(time.Since(*p.LastLogout))))
+
+	return nil
+}
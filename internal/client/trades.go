@@ -0,0 +1,178 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/trade"
+)
+
+const (
+	// listingInterval is how often expired trade listings are removed.
+	listingInterval = time.Hour
+
+	// findLimit is the max number of listings whispered per search.
+	findLimit = 5
+)
+
+// recordListings stores the listings found in a post on the channel, confirming new listings to the poster.
+func (c *Client) recordListings(account string, text string) error {
+	now := time.Now()
+
+	for _, l := range trade.Parse(text) {
+		l.Account = account
+		l.Chat = c.chatID
+		l.CreatedAt = now
+		l.ExpiresAt = now.Add(c.listingExpiry)
+
+		id, created, err := c.trades.AddListing(l)
+		if err != nil {
+			return err
+		}
+
+		// Renewing a listing is part of trading, there's nothing new to confirm.
+		if !created {
+			continue
+		}
+
+		c.whisper(account, fmt.Sprintf("[listed %s %s as #%d for %s, whisper %s %d to remove it]", strings.ToUpper(l.Type), l.Items, id, formatDuration(c.listingExpiry), TypeUnlist, id))
+	}
+
+	return nil
+}
+
+// Find whispers the newest active listings matching every word of the query to the caller.
+func (c *Client) Find(message *Message) error {
+	if !c.tradeListings {
+		c.whisper(message.Account, fmt.Sprintf("[no trade listings on %s]", c.chatID))
+		return nil
+	}
+
+	terms := strings.Fields(strings.ToLower(message.Message))
+	if len(terms) == 0 {
+		c.whisper(message.Account, fmt.Sprintf("[usage: %s <query>]", TypeFind))
+		return nil
+	}
+
+	now := time.Now()
+
+	listings, err := c.trades.FindListings(c.chatID, terms, now, findLimit)
+	if err != nil {
+		return err
+	}
+
+	query := strings.Join(terms, " ")
+
+	if len(listings) == 0 {
+		c.whisper(message.Account, fmt.Sprintf("[no listings matching %s on %s]", query, c.chatID))
+		return nil
+	}
+
+	c.whisper(message.Account, fmt.Sprintf("[newest listings matching %s on %s]", query, c.chatID))
+
+	for _, l := range listings {
+		c.whisper(message.Account, formatListing(l, now))
+	}
+
+	return nil
+}
+
+// Listings whispers the active listings of the caller.
+func (c *Client) Listings(message *Message) error {
+	if !c.tradeListings {
+		c.whisper(message.Account, fmt.Sprintf("[no trade listings on %s]", c.chatID))
+		return nil
+	}
+
+	now := time.Now()
+
+	listings, err := c.trades.FindAccountListings(message.Account, c.chatID, now)
+	if err != nil {
+		return err
+	}
+
+	if len(listings) == 0 {
+		c.whisper(message.Account, fmt.Sprintf("[you have no listings on %s, post WTS, WTB or WTT on %s to list items]", c.chatID, c.chatID))
+		return nil
+	}
+
+	c.whisper(message.Account, fmt.Sprintf("[%d listings on %s, whisper %s <id|all> to remove them]", len(listings), c.chatID, TypeUnlist))
+
+	for _, l := range listings {
+		c.whisper(message.Account, formatListing(l, now))
+	}
+
+	return nil
+}
+
+// Unlist removes a listing of the caller, or all of them.
+func (c *Client) Unlist(message *Message) error {
+	if !c.tradeListings {
+		c.whisper(message.Account, fmt.Sprintf("[no trade listings on %s]", c.chatID))
+		return nil
+	}
+
+	arg := strings.TrimPrefix(strings.ToLower(message.Message), "#")
+
+	if arg == "all" {
+		listings, err := c.trades.FindAccountListings(message.Account, c.chatID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, l := range listings {
+			if _, err := c.trades.RemoveListing(l.ID, message.Account); err != nil {
+				return err
+			}
+		}
+
+		c.whisper(message.Account, fmt.Sprintf("[removed %d listings on %s]", len(listings), c.chatID))
+		return nil
+	}
+
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		c.whisper(message.Account, fmt.Sprintf("[usage: %s <id|all>]", TypeUnlist))
+		return nil
+	}
+
+	removed, err := c.trades.RemoveListing(id, message.Account)
+	if err != nil {
+		return err
+	}
+
+	if !removed {
+		c.whisper(message.Account, fmt.Sprintf("[you have no listing #%d]", id))
+		return nil
+	}
+
+	c.whisper(message.Account, fmt.Sprintf("[removed listing #%d]", id))
+
+	return nil
+}
+
+// expireListings removes expired trade listings every interval.
+func (c *Client) expireListings() {
+	for range time.Tick(listingInterval) {
+		if _, err := c.trades.RemoveExpiredListings(time.Now()); err != nil {
+			log.Printf("failed to remove expired listings %s", err)
+		}
+	}
+}
+
+// formatListing formats a listing for a whisper, such as "[#12 nokka WTS shako, enigma (2 hours ago)]".
+func formatListing(l trade.Listing, now time.Time) string {
+	return fmt.Sprintf("[#%d %s %s %s (%s ago)]", l.ID, l.Account, strings.ToUpper(l.Type), l.Items, formatDuration(now.Sub(l.CreatedAt)))
+}
+
+// postText returns the text of a published message, without the "[account] " prefix.
+func postText(message string) string {
+	if i := strings.Index(message, "] "); i >= 0 && strings.HasPrefix(message, "[") {
+		return message[i+2:]
+	}
+
+	return message
+}
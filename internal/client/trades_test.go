@@ -0,0 +1,60 @@
+package client
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nokka/d2-chatbot/internal/inmem"
+	"github.com/nokka/d2-chatbot/internal/trade"
+)
+
+// fakeTrades keeps listings in memory, listing the same items again renews them.
+type fakeTrades struct {
+	tradeRepository
+	listings []trade.Listing
+}
+
+func (r *fakeTrades) AddListing(l trade.Listing) (int64, bool, error) {
+	for i, existing := range r.listings {
+		if existing.Account == l.Account && existing.Chat == l.Chat && existing.Type == l.Type && existing.Items == l.Items {
+			r.listings[i].ExpiresAt = l.ExpiresAt
+			return int64(i + 1), false, nil
+		}
+	}
+
+	r.listings = append(r.listings, l)
+
+	return int64(len(r.listings)), true, nil
+}
+
+func TestRecordListings(t *testing.T) {
+	c := newTestClient("trade", inmem.NewSubscriberRepository())
+	c.trades = &fakeTrades{}
+
+	posts := []struct {
+		text      string
+		confirmed []string
+	}{
+		{text: "WTS shako", confirmed: []string{"WTS shako as #1"}},
+		{text: "WTS shako WTB ber", confirmed: []string{"WTB ber as #2"}},
+		{text: "WTS shako WTB ber", confirmed: nil},
+	}
+
+	for _, p := range posts {
+		err := c.recordListings("nokka", p.text)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		replies := whispers(c)
+		if len(replies) != len(p.confirmed) {
+			t.Fatalf("expected %d confirmations for %q, got: %q", len(p.confirmed), p.text, replies)
+		}
+
+		for i, want := range p.confirmed {
+			if !strings.Contains(replies[i], want) {
+				t.Fatalf("expected %q in: %q", want, replies[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/trade"
+)
+
+const (
+	// maxWatches is the max number of keywords an account can watch per channel.
+	maxWatches = 10
+
+	// maxKeywordLength is the max length of a watched keyword.
+	maxKeywordLength = 32
+)
+
+// alertWatchers whispers the post to every account watching a keyword in it,
+// unless they already got it as an online subscriber.
+func (c *Client) alertWatchers(sender string, message string) error {
+	watches, err := c.trades.FindWatches(c.chatID)
+	if err != nil {
+		return err
+	}
+
+	text := postText(message)
+	now := time.Now()
+
+	// Alert every watcher once, with the first keyword matching.
+	alerted := make(map[string]struct{})
+
+	for _, w := range watches {
+		if _, ok := alerted[w.Account]; ok || w.Account == sender || !w.Matches(text) {
+			continue
+		}
+
+		// Posts by ignored accounts aren't alerted either.
+		if c.inmem.IsIgnoring(w.Account, sender, c.chatID) {
+			continue
+		}
+
+		alerted[w.Account] = struct{}{}
+
+		if sub := c.inmem.FindSubscriber(w.Account, c.chatID); sub != nil {
+			// Online subscribers already got the post, banned ones don't get any.
+			if sub.Online || sub.Banned(now) {
+				continue
+			}
+		}
+
+		// Don't whisper watchers that aren't online.
+		p, err := c.presence.FindPresence(w.Account)
+		if err != nil {
+			return err
+		}
+
+		if p == nil || !p.Online() {
+			continue
+		}
+
+		c.whisper(w.Account, fmt.Sprintf("[%s: %s] %s", c.chatID, w.Keyword, message))
+	}
+
+	return nil
+}
+
+// Watch adds a keyword to the watches of the caller, or lists them without a keyword.
+func (c *Client) Watch(message *Message) error {
+	if !c.tradeListings {
+		c.whisper(message.Account, fmt.Sprintf("[no trade posts to watch on %s]", c.chatID))
+		return nil
+	}
+
+	keyword := strings.Join(strings.Fields(strings.ToLower(message.Message)), " ")
+
+	watches, err := c.trades.FindAccountWatches(message.Account, c.chatID)
+	if err != nil {
+		return err
+	}
+
+	if keyword == "" {
+		if len(watches) == 0 {
+			c.whisper(message.Account, fmt.Sprintf("[you're not watching anything on %s, whisper %s <keyword> to watch posts]", c.chatID, TypeWatch))
+			return nil
+		}
+
+		keywords := make([]string, 0, len(watches))
+		for _, w := range watches {
+			keywords = append(keywords, w.Keyword)
+		}
+
+		c.whisper(message.Account, fmt.Sprintf("[watching %d keywords on %s]", len(keywords), c.chatID))
+
+		for _, line := range chunk(keywords, maxWhisperLength) {
+			c.whisper(message.Account, line)
+		}
+
+		return nil
+	}
+
+	if len(keyword) > maxKeywordLength {
+		c.whisper(message.Account, fmt.Sprintf("[keywords can be at most %d characters]", maxKeywordLength))
+		return nil
+	}
+
+	for _, w := range watches {
+		if w.Keyword == keyword {
+			c.whisper(message.Account, fmt.Sprintf("[already watching %s on %s]", keyword, c.chatID))
+			return nil
+		}
+	}
+
+	if len(watches) >= maxWatches {
+		c.whisper(message.Account, fmt.Sprintf("[you can watch at most %d keywords, remove one with %s <keyword>]", maxWatches, TypeUnwatch))
+		return nil
+	}
+
+	err = c.trades.AddWatch(trade.Watch{Account: message.Account, Chat: c.chatID, Keyword: keyword})
+	if err != nil {
+		return err
+	}
+
+	c.whisper(message.Account, fmt.Sprintf("[watching %s on %s]", keyword, c.chatID))
+
+	return nil
+}
+
+// Unwatch removes a keyword from the watches of the caller, or all of them.
+func (c *Client) Unwatch(message *Message) error {
+	if !c.tradeListings {
+		c.whisper(message.Account, fmt.Sprintf("[no trade posts to watch on %s]", c.chatID))
+		return nil
+	}
+
+	keyword := strings.Join(strings.Fields(strings.ToLower(message.Message)), " ")
+	if keyword == "" {
+		c.whisper(message.Account, fmt.Sprintf("[usage: %s <keyword|all>]", TypeUnwatch))
+		return nil
+	}
+
+	if keyword == "all" {
+		n, err := c.trades.RemoveWatches(message.Account, c.chatID, "")
+		if err != nil {
+			return err
+		}
+
+		c.whisper(message.Account, fmt.Sprintf("[removed %d watches on %s]", n, c.chatID))
+		return nil
+	}
+
+	n, err := c.trades.RemoveWatches(message.Account, c.chatID, keyword)
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		c.whisper(message.Account, fmt.Sprintf("[you're not watching %s on %s]", keyword, c.chatID))
+		return nil
+	}
+
+	c.whisper(message.Account, fmt.Sprintf("[no longer watching %s on %s]", keyword, c.chatID))
+
+	return nil
+}
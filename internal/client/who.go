@@ -0,0 +1,138 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/role"
+	"github.com/nokka/d2-chatbot/internal/subscriber"
+)
+
+const (
+	// maxWhisperLength is the max length of a whisper, leaving room
+	// for the whisper command itself within the D2 message limit.
+	maxWhisperLength = 200
+
+	// whoPageSize is the number of accounts listed per page.
+	whoPageSize = 40
+)
+
+// Who whispers the number of online subscribers and a page of their accounts to the caller.
+// Callers allowed to view members can add "all" to include offline and banned subscribers.
+func (c *Client) Who(message *Message) error {
+	args := strings.Fields(strings.ToLower(message.Message))
+
+	all := len(args) > 0 && args[0] == "all"
+	if all {
+		if !c.allowed(message.Account, role.PermissionViewMembers) {
+			c.whisper(message.Account, "[insufficient privileges]")
+			return nil
+		}
+		args = args[1:]
+	}
+
+	page := 1
+	if len(args) > 0 {
+		p, err := strconv.Atoi(args[0])
+		if err != nil || p < 1 {
+			c.whisper(message.Account, fmt.Sprintf("[usage: %s [all] [page]]", TypeWho))
+			return nil
+		}
+		page = p
+	}
+
+	var (
+		subs []subscriber.Subscriber
+		err  error
+	)
+
+	if all {
+		subs, err = c.inmem.FindSubscribers(c.chatID)
+	} else {
+		subs, err = c.inmem.FindEligibleSubscribers(c.chatID)
+	}
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].Account < subs[j].Account
+	})
+
+	now := time.Now()
+	accounts := make([]string, 0, len(subs))
+	var online int
+
+	for _, sub := range subs {
+		name := sub.Account
+
+		switch {
+		case sub.Banned(now):
+			name += " (banned)"
+		case !sub.Online:
+			name += " (offline)"
+		default:
+			online++
+		}
+
+		accounts = append(accounts, name)
+	}
+
+	pages := (len(accounts) + whoPageSize - 1) / whoPageSize
+	if pages == 0 {
+		pages = 1
+	}
+
+	if page > pages {
+		c.whisper(message.Account, fmt.Sprintf("[there are only %d pages]", pages))
+		return nil
+	}
+
+	if all {
+		c.whisper(message.Account, fmt.Sprintf("[%d subscribers on %s, %d online, page %d/%d]", len(subs), c.chatID, online, page, pages))
+	} else {
+		c.whisper(message.Account, fmt.Sprintf("[%d online on %s, page %d/%d]", online, c.chatID, page, pages))
+	}
+
+	start := (page - 1) * whoPageSize
+	end := start + whoPageSize
+	if end > len(accounts) {
+		end = len(accounts)
+	}
+
+	for _, line := range chunk(accounts[start:end], maxWhisperLength) {
+		c.whisper(message.Account, line)
+	}
+
+	return nil
+}
+
+// chunk joins the items into lines no longer than max, an item longer than max gets a line of its own.
+func chunk(items []string, max int) []string {
+	var (
+		lines []string
+		line  string
+	)
+
+	for _, item := range items {
+		if line != "" && len(line)+len(", ")+len(item) > max {
+			lines = append(lines, line)
+			line = ""
+		}
+
+		if line != "" {
+			line += ", "
+		}
+
+		line += item
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return lines
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/inmem"
+	"github.com/nokka/d2-chatbot/internal/role"
+	"github.com/nokka/d2-chatbot/internal/subscriber"
+)
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []string
+		max   int
+		lines []string
+	}{
+		{
+			name:  "empty",
+			items: nil,
+			max:   10,
+			lines: nil,
+		},
+		{
+			name:  "single line",
+			items: []string{"ab", "cd"},
+			max:   10,
+			lines: []string{"ab, cd"},
+		},
+		{
+			name:  "exact fit",
+			items: []string{"abc", "def"},
+			max:   8,
+			lines: []string{"abc, def"},
+		},
+		{
+			name:  "split",
+			items: []string{"abc", "def", "ghi"},
+			max:   9,
+			lines: []string{"abc, def", "ghi"},
+		},
+		{
+			name:  "item longer than max",
+			items: []string{"abcdefghijkl", "ab"},
+			max:   5,
+			lines: []string{"abcdefghijkl", "ab"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if lines := chunk(tt.items, tt.max); !reflect.DeepEqual(tt.lines, lines) {
+				t.Fatalf("expected: %q, got: %q", tt.lines, lines)
+			}
+		})
+	}
+}
+
+func TestWho(t *testing.T) {
+	banned := time.Now().Add(time.Hour)
+
+	subs := []subscriber.Subscriber{
+		{Account: "nokka", Online: true},
+		{Account: "meanski", Online: false},
+		{Account: "bruse", Online: true, BannedUntil: &banned},
+	}
+
+	// Enough online subscribers for two pages.
+	for i := 0; i < 45; i++ {
+		subs = append(subs, subscriber.Subscriber{Account: fmt.Sprintf("acc%02d", i), Online: true})
+	}
+
+	repo := inmem.NewSubscriberRepository()
+	repo.SyncSubscribers("chat", subs)
+	repo.SyncRoles([]role.Role{{Account: "trog", Chat: "chat", Name: role.Moderator}})
+
+	tests := []struct {
+		name     string
+		account  string
+		message  string
+		header   string
+		contains []string
+		missing  []string
+	}{
+		{
+			name:     "online",
+			account:  "nokka",
+			header:   "[46 online on chat, page 1/2]",
+			contains: []string{"acc00", "acc39"},
+			missing:  []string{"acc40", "bruse", "meanski"},
+		},
+		{
+			name:     "second page",
+			account:  "nokka",
+			message:  "2",
+			header:   "[46 online on chat, page 2/2]",
+			contains: []string{"acc40, acc41, acc42, acc43, acc44, nokka"},
+			missing:  []string{"bruse", "meanski"},
+		},
+		{
+			name:    "page out of range",
+			account: "nokka",
+			message: "3",
+			header:  "[there are only 2 pages]",
+		},
+		{
+			name:    "invalid page",
+			account: "nokka",
+			message: "0",
+			header:  "[usage: who [all] [page]]",
+		},
+		{
+			name:    "all without privileges",
+			account: "nokka",
+			message: "all",
+			header:  "[insufficient privileges]",
+		},
+		{
+			name:     "all",
+			account:  "trog",
+			message:  "all 2",
+			header:   "[48 subscribers on chat, 46 online, page 2/2]",
+			contains: []string{"bruse (banned)", "meanski (offline)", "nokka"},
+			missing:  []string{"nokka ("},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient("chat", repo)
+
+			err := c.Who(&Message{Account: tt.account, Cmd: TypeWho, Message: tt.message})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			replies := whispers(c)
+			if len(replies) == 0 || replies[0] != tt.header {
+				t.Fatalf("expected header: %q, got: %q", tt.header, replies)
+			}
+
+			listing := strings.Join(replies[1:], ", ")
+
+			for _, want := range tt.contains {
+				if !strings.Contains(listing, want) {
+					t.Fatalf("expected %q in: %q", want, listing)
+				}
+			}
+
+			for _, unwanted := range tt.missing {
+				if strings.Contains(listing, unwanted) {
+					t.Fatalf("expected no %q in: %q", unwanted, listing)
+				}
+			}
+		})
+	}
+}
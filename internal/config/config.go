@@ -0,0 +1,299 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/nokka/d2-chatbot/pkg/env"
+	"gopkg.in/yaml.v2"
+)
+
+// Presence sources.
+const (
+	SourceFile   = "file"
+	SourceSyslog = "syslog"
+	SourceStatus = "status"
+)
+
+// Config is the configuration of the chat bot.
+type Config struct {
+	Channels []Channel `yaml:"channels"`
+	Presence Presence  `yaml:"presence"`
+}
+
+// Presence configures where the online state of accounts comes from.
+type Presence struct {
+	// Source is one of file, syslog or status, defaults to file.
+	Source string `yaml:"source"`
+
+	// File is the bnetd.log tailed by the file source, defaults to BNETD_LOG.
+	File string `yaml:"file"`
+
+	// Position is where the file source persists how far it has read, defaults to BNETD_POSITION.
+	// PositionDisabled turns persisting off, the file is then followed from the end on every start.
+	Position string `yaml:"position"`
+
+	// SyslogNetwork and SyslogAddress is where the syslog source listens, the network is udp or tcp.
+	SyslogNetwork string `yaml:"syslog_network"`
+	SyslogAddress string `yaml:"syslog_address"`
+
+	// StatusFile is the PvpGN status dump polled every PollInterval by the status source.
+	StatusFile   string        `yaml:"status_file"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+}
+
+// Channel is the configuration of a single chat channel and the bot account serving it.
+type Channel struct {
+	// ID is the name of the channel, used to store subscriptions.
+	ID string `yaml:"id"`
+
+	// Account is the bot account used to serve the channel, defaults to the ID.
+	Account string `yaml:"account"`
+
+	// Password is the password of the bot account, if PasswordEnv is set
+	// the password is read from that environment variable instead.
+	Password    string `yaml:"password"`
+	PasswordEnv string `yaml:"password_env"`
+
+	// Options are the tunable settings of the channel.
+	Options Options `yaml:"options"`
+}
+
+// Options holds the tunable settings of a channel, unset options get defaults.
+type Options struct {
+	// WhisperRate is the number of whispers the bot sends per second.
+	WhisperRate float64 `yaml:"whisper_rate"`
+
+	// WhisperBurst is the number of whispers the bot can send at once before being throttled.
+	WhisperBurst int `yaml:"whisper_burst"`
+
+	// WhisperQueueSize is the max number of whispers waiting to be sent.
+	WhisperQueueSize int `yaml:"whisper_queue_size"`
+
+	// RateLimit is the number of messages an account can post per RateWindow, -1 disables rate limiting.
+	RateLimit  int           `yaml:"rate_limit"`
+	RateWindow time.Duration `yaml:"rate_window"`
+
+	// SlowMode is the interval between messages when a moderator enables slow mode, -1 disables slow mode.
+	SlowMode time.Duration `yaml:"slow_mode"`
+
+	// LFGExpiry is how long looking for group posts stay on the board.
+	LFGExpiry time.Duration `yaml:"lfg_expiry"`
+
+	// TradeListings enables storing WTS, WTB and WTT posts as listings that can be searched for ListingExpiry.
+	TradeListings bool          `yaml:"trade_listings"`
+	ListingExpiry time.Duration `yaml:"listing_expiry"`
+
+	// HistorySize is the number of latest messages kept in memory, HistoryRetention how long they're kept.
+	HistorySize      int           `yaml:"history_size"`
+	HistoryRetention time.Duration `yaml:"history_retention"`
+}
+
+// Default options, the whisper rate matches the default PvpGN flood
+// protection of 5 lines per 5 seconds.
+const (
+	DefaultWhisperRate      = 1
+	DefaultWhisperBurst     = 5
+	DefaultWhisperQueueSize = 1000
+	DefaultRateLimit        = 5
+	DefaultRateWindow       = time.Minute
+	DefaultSlowMode         = 30 * time.Second
+	DefaultLFGExpiry        = 30 * time.Minute
+	DefaultListingExpiry    = 24 * time.Hour
+	DefaultHistorySize      = 100
+	DefaultHistoryRetention = 24 * time.Hour
+	DefaultSyslogNetwork    = "udp"
+	DefaultPollInterval     = 10 * time.Second
+	DefaultPosition         = "bnetd.position"
+)
+
+// PositionDisabled is the position that turns off persisting the position of the file source.
+const PositionDisabled = "-"
+
+// Load reads the config file at the given path and validates it.
+func Load(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data, env.DefaultClient)
+}
+
+// Parse decodes the given yaml config, resolves passwords from
+// the environment and validates the result.
+func Parse(data []byte, e *env.Client) (*Config, error) {
+	var cfg Config
+
+	err := yaml.UnmarshalStrict(data, &cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Channels) == 0 {
+		return nil, errors.New("no channels configured")
+	}
+
+	seen := make(map[string]struct{})
+
+	for i := range cfg.Channels {
+		ch := &cfg.Channels[i]
+
+		if ch.ID == "" {
+			return nil, fmt.Errorf("channel %d is missing an id", i)
+		}
+
+		if _, ok := seen[ch.ID]; ok {
+			return nil, fmt.Errorf("channel %s is configured more than once", ch.ID)
+		}
+
+		seen[ch.ID] = struct{}{}
+
+		if ch.Account == "" {
+			ch.Account = ch.ID
+		}
+
+		if ch.PasswordEnv != "" {
+			ch.Password = e.String(ch.PasswordEnv, ch.Password)
+		}
+
+		if ch.Password == "" {
+			return nil, fmt.Errorf("password not set for channel %s", ch.ID)
+		}
+
+		err := ch.Options.validate()
+		if err != nil {
+			return nil, fmt.Errorf("invalid options for channel %s: %s", ch.ID, err)
+		}
+	}
+
+	err = cfg.Presence.validate(e)
+	if err != nil {
+		return nil, fmt.Errorf("invalid presence: %s", err)
+	}
+
+	return &cfg, nil
+}
+
+// validate sets defaults on unset options and makes sure the rest are valid.
+func (o *Options) validate() error {
+	if o.WhisperRate == 0 {
+		o.WhisperRate = DefaultWhisperRate
+	}
+
+	if o.WhisperBurst == 0 {
+		o.WhisperBurst = DefaultWhisperBurst
+	}
+
+	if o.WhisperQueueSize == 0 {
+		o.WhisperQueueSize = DefaultWhisperQueueSize
+	}
+
+	if o.RateLimit == 0 {
+		o.RateLimit = DefaultRateLimit
+	}
+
+	if o.RateWindow == 0 {
+		o.RateWindow = DefaultRateWindow
+	}
+
+	if o.SlowMode == 0 {
+		o.SlowMode = DefaultSlowMode
+	}
+
+	if o.LFGExpiry == 0 {
+		o.LFGExpiry = DefaultLFGExpiry
+	}
+
+	if o.ListingExpiry == 0 {
+		o.ListingExpiry = DefaultListingExpiry
+	}
+
+	if o.HistorySize == 0 {
+		o.HistorySize = DefaultHistorySize
+	}
+
+	if o.HistoryRetention == 0 {
+		o.HistoryRetention = DefaultHistoryRetention
+	}
+
+	if o.WhisperRate < 0 || o.WhisperBurst < 0 || o.WhisperQueueSize < 0 {
+		return errors.New("whisper options can't be negative")
+	}
+
+	if o.RateWindow < 0 {
+		return errors.New("rate limit options can't be negative")
+	}
+
+	// Since 0 means the default, rate limiting and slow mode are disabled by any negative value.
+	if o.RateLimit < 0 {
+		o.RateLimit = 0
+	}
+
+	if o.SlowMode < 0 {
+		o.SlowMode = 0
+	}
+
+	if o.LFGExpiry < 0 || o.ListingExpiry < 0 {
+		return errors.New("expiry options can't be negative")
+	}
+
+	if o.HistorySize < 0 || o.HistoryRetention < 0 {
+		return errors.New("history options can't be negative")
+	}
+
+	return nil
+}
+
+// validate sets defaults, falling back on the environment for the file source, and validates the source.
+func (p *Presence) validate(e *env.Client) error {
+	if p.Source == "" {
+		p.Source = SourceFile
+	}
+
+	if p.File == "" {
+		p.File = e.String("BNETD_LOG", "")
+	}
+
+	if p.Position == "" {
+		p.Position = e.String("BNETD_POSITION", DefaultPosition)
+	}
+
+	// The file source doesn't persist the position without a path.
+	if p.Position == PositionDisabled {
+		p.Position = ""
+	}
+
+	if p.SyslogNetwork == "" {
+		p.SyslogNetwork = DefaultSyslogNetwork
+	}
+
+	if p.PollInterval == 0 {
+		p.PollInterval = DefaultPollInterval
+	}
+
+	switch p.Source {
+	case SourceFile:
+		if p.File == "" {
+			return errors.New("file source requires a file")
+		}
+	case SourceSyslog:
+		if p.SyslogAddress == "" {
+			return errors.New("syslog source requires a syslog_address")
+		}
+	case SourceStatus:
+		if p.StatusFile == "" {
+			return errors.New("status source requires a status_file")
+		}
+
+		if p.PollInterval < 0 {
+			return errors.New("poll_interval can't be negative")
+		}
+	default:
+		return fmt.Errorf("unknown source %q", p.Source)
+	}
+
+	return nil
+}
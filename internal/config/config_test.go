@@ -0,0 +1,277 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nokka/d2-chatbot/pkg/env"
+)
+
+func TestParse(t *testing.T) {
+	e := &env.Client{Getenv: func(key string) (string, bool) {
+		value, found := map[string]string{
+			"TRADE_PASSWORD": "secret",
+			"BNETD_LOG":      "/var/log/bnetd.log",
+		}[key]
+
+		return value, found
+	}}
+
+	defaults := Options{
+		WhisperRate:      DefaultWhisperRate,
+		WhisperBurst:     DefaultWhisperBurst,
+		WhisperQueueSize: DefaultWhisperQueueSize,
+		RateLimit:        DefaultRateLimit,
+		RateWindow:       DefaultRateWindow,
+		SlowMode:         DefaultSlowMode,
+		LFGExpiry:        DefaultLFGExpiry,
+		ListingExpiry:    DefaultListingExpiry,
+		HistorySize:      DefaultHistorySize,
+		HistoryRetention: DefaultHistoryRetention,
+	}
+
+	presence := Presence{
+		Source:        SourceFile,
+		File:          "/var/log/bnetd.log",
+		Position:      DefaultPosition,
+		SyslogNetwork: DefaultSyslogNetwork,
+		PollInterval:  DefaultPollInterval,
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		cfg   *Config
+		valid bool
+	}{
+		{
+			name: "valid channels",
+			input: `
+channels:
+  - id: chat
+    password: hunter2
+  - id: trade
+    account: tradebot
+    password_env: TRADE_PASSWORD
+`,
+			cfg: &Config{
+				Channels: []Channel{
+					{ID: "chat", Account: "chat", Password: "hunter2", Options: defaults},
+					{ID: "trade", Account: "tradebot", Password: "secret", PasswordEnv: "TRADE_PASSWORD", Options: defaults},
+				},
+				Presence: presence,
+			},
+			valid: true,
+		},
+		{
+			name: "channel options",
+			input: `
+channels:
+  - id: chat
+    password: hunter2
+    options:
+      whisper_rate: 2.5
+      whisper_queue_size: 50
+      rate_limit: 3
+      rate_window: 10s
+      lfg_expiry: 1h
+      trade_listings: true
+      history_size: 20
+`,
+			cfg: &Config{
+				Channels: []Channel{
+					{
+						ID:       "chat",
+						Account:  "chat",
+						Password: "hunter2",
+						Options: Options{
+							WhisperRate:      2.5,
+							WhisperBurst:     DefaultWhisperBurst,
+							WhisperQueueSize: 50,
+							RateLimit:        3,
+							RateWindow:       10 * time.Second,
+							SlowMode:         DefaultSlowMode,
+							LFGExpiry:        time.Hour,
+							TradeListings:    true,
+							ListingExpiry:    DefaultListingExpiry,
+							HistorySize:      20,
+							HistoryRetention: DefaultHistoryRetention,
+						},
+					},
+				},
+				Presence: presence,
+			},
+			valid: true,
+		},
+		{
+			name: "rate limiting disabled",
+			input: `
+channels:
+  - id: chat
+    password: hunter2
+    options:
+      rate_limit: -1
+      slow_mode: -1
+`,
+			cfg: &Config{
+				Channels: []Channel{
+					{
+						ID:       "chat",
+						Account:  "chat",
+						Password: "hunter2",
+						Options: Options{
+							WhisperRate:      DefaultWhisperRate,
+							WhisperBurst:     DefaultWhisperBurst,
+							WhisperQueueSize: DefaultWhisperQueueSize,
+							RateWindow:       DefaultRateWindow,
+							LFGExpiry:        DefaultLFGExpiry,
+							ListingExpiry:    DefaultListingExpiry,
+							HistorySize:      DefaultHistorySize,
+							HistoryRetention: DefaultHistoryRetention,
+						},
+					},
+				},
+				Presence: presence,
+			},
+			valid: true,
+		},
+		{
+			name: "negative options",
+			input: `
+channels:
+  - id: chat
+    password: hunter2
+    options:
+      whisper_burst: -1
+`,
+			valid: false,
+		},
+		{
+			name: "syslog presence",
+			input: `
+channels:
+  - id: chat
+    password: hunter2
+presence:
+  source: syslog
+  syslog_network: tcp
+  syslog_address: ":5140"
+`,
+			cfg: &Config{
+				Channels: []Channel{
+					{ID: "chat", Account: "chat", Password: "hunter2", Options: defaults},
+				},
+				Presence: Presence{
+					Source:        SourceSyslog,
+					File:          "/var/log/bnetd.log",
+					Position:      DefaultPosition,
+					SyslogNetwork: "tcp",
+					SyslogAddress: ":5140",
+					PollInterval:  DefaultPollInterval,
+				},
+			},
+			valid: true,
+		},
+		{
+			name: "position disabled",
+			input: `
+channels:
+  - id: chat
+    password: hunter2
+presence:
+  position: "-"
+`,
+			cfg: &Config{
+				Channels: []Channel{
+					{ID: "chat", Account: "chat", Password: "hunter2", Options: defaults},
+				},
+				Presence: Presence{
+					Source:        SourceFile,
+					File:          "/var/log/bnetd.log",
+					SyslogNetwork: DefaultSyslogNetwork,
+					PollInterval:  DefaultPollInterval,
+				},
+			},
+			valid: true,
+		},
+		{
+			name: "status presence without file",
+			input: `
+channels:
+  - id: chat
+    password: hunter2
+presence:
+  source: status
+`,
+			valid: false,
+		},
+		{
+			name: "unknown presence source",
+			input: `
+channels:
+  - id: chat
+    password: hunter2
+presence:
+  source: telepathy
+`,
+			valid: false,
+		},
+		{
+			name:  "no channels",
+			input: `channels: []`,
+			valid: false,
+		},
+		{
+			name: "missing id",
+			input: `
+channels:
+  - password: hunter2
+`,
+			valid: false,
+		},
+		{
+			name: "duplicate id",
+			input: `
+channels:
+  - id: chat
+    password: hunter2
+  - id: chat
+    password: hunter2
+`,
+			valid: false,
+		},
+		{
+			name: "missing password",
+			input: `
+channels:
+  - id: pvp
+    password_env: PVP_PASSWORD
+`,
+			valid: false,
+		},
+		{
+			name: "unknown field",
+			input: `
+channels:
+  - id: chat
+    pasword: hunter2
+`,
+			valid: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(tt.input), e)
+
+			if tt.valid != (err == nil) {
+				t.Fatalf("expected valid = %v; got err = %v", tt.valid, err)
+			}
+
+			if !reflect.DeepEqual(tt.cfg, cfg) {
+				t.Fatalf("expected: %v, got: %v", tt.cfg, cfg)
+			}
+		})
+	}
+}
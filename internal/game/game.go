@@ -0,0 +1,28 @@
+package game
+
+import (
+	"path"
+	"strings"
+)
+
+// AllGames is the pattern matching every game.
+const AllGames = "*"
+
+// Filter opts an account into announcements of the games created with a name matching the pattern.
+type Filter struct {
+	Account string
+	Chat    string
+	Pattern string
+}
+
+// Matches reports whether the name of the game matches the pattern, ignoring case.
+func (f Filter) Matches(name string) bool {
+	matched, err := path.Match(strings.ToLower(f.Pattern), strings.ToLower(name))
+	return err == nil && matched
+}
+
+// ValidPattern reports whether the pattern is well formed, such as "baal*" or "cows-??".
+func ValidPattern(pattern string) bool {
+	_, err := path.Match(pattern, "")
+	return err == nil
+}
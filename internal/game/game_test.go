@@ -0,0 +1,28 @@
+package game
+
+import "testing"
+
+func TestFilterMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		game    string
+		matches bool
+	}{
+		{name: "all games", pattern: AllGames, game: "baal-12", matches: true},
+		{name: "prefix", pattern: "baal*", game: "baal-12", matches: true},
+		{name: "prefix ignores case", pattern: "baal*", game: "BAAL run 3", matches: true},
+		{name: "other prefix", pattern: "cows*", game: "baal-12", matches: false},
+		{name: "single character", pattern: "cows-?", game: "cows-7", matches: true},
+		{name: "exact", pattern: "trist", game: "trist-2", matches: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Filter{Pattern: tt.pattern}
+			if matches := f.Matches(tt.game); matches != tt.matches {
+				t.Fatalf("expected matches = %v; got = %v", tt.matches, matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package history
+
+import "time"
+
+// Message is a message published on a chat.
+type Message struct {
+	Chat      string
+	Account   string
+	Text      string
+	CreatedAt time.Time
+}
+
+// Mention is a message mentioning an account while it was offline, waiting to be delivered.
+type Mention struct {
+	Account   string
+	Chat      string
+	Sender    string
+	Text      string
+	CreatedAt time.Time
+}
@@ -5,39 +5,70 @@ import (
 	"sync"
 	"time"
 
+	"github.com/nokka/d2-chatbot/internal/game"
+	"github.com/nokka/d2-chatbot/internal/role"
 	"github.com/nokka/d2-chatbot/internal/subscriber"
 )
 
 // SubscriberRepository is an in memory repository used to store subscribers.
 type SubscriberRepository struct {
-	Chats      map[string]map[string]subscriber.Subscriber
-	Moderators []string
-	rwm        sync.RWMutex
+	Chats map[string]map[string]subscriber.Subscriber
+	Roles map[string][]role.Role
+	// GameFilters holds the game announcement filters per chat.
+	GameFilters map[string][]game.Filter
+	// Ignores holds the ignores per account.
+	Ignores map[string][]subscriber.Ignore
+	rwm     sync.RWMutex
 }
 
-// SyncSubscribers syncs the given subscribers to memory.
+// SyncSubscribers syncs the given subscribers to memory, creating the chat if it doesn't exist.
 func (r *SubscriberRepository) SyncSubscribers(chatID string, subscribers []subscriber.Subscriber) error {
 	r.rwm.Lock()
 	defer r.rwm.Unlock()
 
-	// Make sure chat exists.
-	if _, ok := r.Chats[chatID]; ok {
-		for i, sub := range subscribers {
-			r.Chats[chatID][sub.Account] = subscribers[i]
-		}
+	// Create the chat the first time it's synced.
+	if _, ok := r.Chats[chatID]; !ok {
+		r.Chats[chatID] = make(map[string]subscriber.Subscriber)
+	}
+
+	for i, sub := range subscribers {
+		r.Chats[chatID][sub.Account] = subscribers[i]
+	}
+
+	return nil
+}
+
+// SyncRoles syncs the given roles to memory.
+func (r *SubscriberRepository) SyncRoles(roles []role.Role) {
+	r.rwm.Lock()
+	defer r.rwm.Unlock()
 
-		return nil
+	r.Roles = make(map[string][]role.Role)
+	for _, rl := range roles {
+		r.Roles[rl.Account] = append(r.Roles[rl.Account], rl)
 	}
+}
 
-	return errors.New("unable to sync, chat not found")
+// SyncGameFilters syncs the given game filters to memory.
+func (r *SubscriberRepository) SyncGameFilters(filters []game.Filter) {
+	r.rwm.Lock()
+	defer r.rwm.Unlock()
+
+	r.GameFilters = make(map[string][]game.Filter)
+	for _, f := range filters {
+		r.GameFilters[f.Chat] = append(r.GameFilters[f.Chat], f)
+	}
 }
 
-// SyncModerators syncs the given moderator names to memory.
-func (r *SubscriberRepository) SyncModerators(moderators []string) {
+// SyncIgnores syncs the given ignores to memory.
+func (r *SubscriberRepository) SyncIgnores(ignores []subscriber.Ignore) {
 	r.rwm.Lock()
 	defer r.rwm.Unlock()
 
-	r.Moderators = moderators
+	r.Ignores = make(map[string][]subscriber.Ignore)
+	for _, ig := range ignores {
+		r.Ignores[ig.Account] = append(r.Ignores[ig.Account], ig)
+	}
 }
 
 // FindSubscriber looks through the in memory map to find a subscriber on the given chat.
@@ -82,7 +113,7 @@ func (r *SubscriberRepository) FindEligibleSubscribers(chatID string) ([]subscri
 		var subs []subscriber.Subscriber
 		for _, sub := range chat {
 			// The subscriber is eligible for messages if they're both online and not currently banned.
-			if sub.Online && (sub.BannedUntil == nil || sub.BannedUntil.Before(time.Now())) {
+			if sub.Online && !sub.Banned(time.Now()) {
 				subs = append(subs, sub)
 			}
 		}
@@ -145,6 +176,26 @@ func (r *SubscriberRepository) UpdateOnlineStatus(account string, online bool) e
 	return nil
 }
 
+// SyncOnlineStatus marks the given accounts online and every other account offline.
+func (r *SubscriberRepository) SyncOnlineStatus(online []string) error {
+	r.rwm.Lock()
+	defer r.rwm.Unlock()
+
+	accounts := make(map[string]struct{}, len(online))
+	for _, account := range online {
+		accounts[account] = struct{}{}
+	}
+
+	for id, chat := range r.Chats {
+		for account, subscriber := range chat {
+			_, subscriber.Online = accounts[account]
+			r.Chats[id][account] = subscriber
+		}
+	}
+
+	return nil
+}
+
 // SubscriberExists checks if a given account exists.
 func (r *SubscriberRepository) SubscriberExists(account string) bool {
 	r.rwm.RLock()
@@ -160,8 +211,8 @@ func (r *SubscriberRepository) SubscriberExists(account string) bool {
 	return false
 }
 
-// UpdateBannedUntil updates the ban time.
-func (r *SubscriberRepository) UpdateBannedUntil(account string, chatID string, until *time.Time) error {
+// UpdateBan updates the ban time, or bans the account permanently.
+func (r *SubscriberRepository) UpdateBan(account string, chatID string, until *time.Time, permanent bool) error {
 	r.rwm.Lock()
 	defer r.rwm.Unlock()
 
@@ -170,6 +221,7 @@ func (r *SubscriberRepository) UpdateBannedUntil(account string, chatID string,
 		// Make sure subscriber exists.
 		if subscriber, ok := chat[account]; ok {
 			subscriber.BannedUntil = until
+			subscriber.BannedPermanently = permanent
 			r.Chats[chatID][account] = subscriber
 		}
 	} else {
@@ -179,21 +231,189 @@ func (r *SubscriberRepository) UpdateBannedUntil(account string, chatID string,
 	return nil
 }
 
-// FindModerators finds all moderators.
-func (r *SubscriberRepository) FindModerators() ([]string, error) {
+// UpdateDigest turns the login digest of a subscriber on or off.
+func (r *SubscriberRepository) UpdateDigest(account string, chatID string, enabled bool) error {
+	r.rwm.Lock()
+	defer r.rwm.Unlock()
+
+	// Make sure chat exists.
+	if chat, ok := r.Chats[chatID]; ok {
+		// Make sure subscriber exists.
+		if subscriber, ok := chat[account]; ok {
+			subscriber.Digest = enabled
+			r.Chats[chatID][account] = subscriber
+		}
+	} else {
+		return errors.New("failed to update digest, chat id doesn't exist")
+	}
+
+	return nil
+}
+
+// UpdateMentions turns mention notifications of a subscriber on or off.
+func (r *SubscriberRepository) UpdateMentions(account string, chatID string, enabled bool) error {
+	r.rwm.Lock()
+	defer r.rwm.Unlock()
+
+	// Make sure chat exists.
+	if chat, ok := r.Chats[chatID]; ok {
+		// Make sure subscriber exists.
+		if subscriber, ok := chat[account]; ok {
+			subscriber.MentionsDisabled = !enabled
+			r.Chats[chatID][account] = subscriber
+		}
+	} else {
+		return errors.New("failed to update mentions, chat id doesn't exist")
+	}
+
+	return nil
+}
+
+// FindAccountRoles finds all roles held by the account.
+func (r *SubscriberRepository) FindAccountRoles(account string) []role.Role {
 	r.rwm.RLock()
 	defer r.rwm.RUnlock()
-	return r.Moderators, nil
+	return r.Roles[account]
+}
+
+// AddRole grants a role to an account.
+func (r *SubscriberRepository) AddRole(rl role.Role) error {
+	r.rwm.Lock()
+	defer r.rwm.Unlock()
+
+	for _, existing := range r.Roles[rl.Account] {
+		if existing == rl {
+			return nil
+		}
+	}
+
+	r.Roles[rl.Account] = append(r.Roles[rl.Account], rl)
+
+	return nil
+}
+
+// RemoveRole revokes a role from an account.
+func (r *SubscriberRepository) RemoveRole(rl role.Role) error {
+	r.rwm.Lock()
+	defer r.rwm.Unlock()
+
+	// Copy the roles since FindAccountRoles hands out the slice.
+	roles := make([]role.Role, 0, len(r.Roles[rl.Account]))
+	for _, existing := range r.Roles[rl.Account] {
+		if existing != rl {
+			roles = append(roles, existing)
+		}
+	}
+
+	r.Roles[rl.Account] = roles
+
+	return nil
+}
+
+// FindGameFilters finds all game filters on the chat.
+func (r *SubscriberRepository) FindGameFilters(chatID string) []game.Filter {
+	r.rwm.RLock()
+	defer r.rwm.RUnlock()
+	return r.GameFilters[chatID]
+}
+
+// AddGameFilter opts an account into announcements of games matching the filter.
+func (r *SubscriberRepository) AddGameFilter(f game.Filter) error {
+	r.rwm.Lock()
+	defer r.rwm.Unlock()
+
+	for _, existing := range r.GameFilters[f.Chat] {
+		if existing == f {
+			return nil
+		}
+	}
+
+	r.GameFilters[f.Chat] = append(r.GameFilters[f.Chat], f)
+
+	return nil
+}
+
+// RemoveGameFilters removes the game filter with the pattern, or all filters of the account on the chat if it's empty.
+func (r *SubscriberRepository) RemoveGameFilters(account string, chatID string, pattern string) error {
+	r.rwm.Lock()
+	defer r.rwm.Unlock()
+
+	// Copy the filters since FindGameFilters hands out the slice.
+	filters := make([]game.Filter, 0, len(r.GameFilters[chatID]))
+	for _, f := range r.GameFilters[chatID] {
+		if f.Account == account && (pattern == "" || f.Pattern == pattern) {
+			continue
+		}
+
+		filters = append(filters, f)
+	}
+
+	r.GameFilters[chatID] = filters
+
+	return nil
+}
+
+// FindIgnores finds all ignores of the account.
+func (r *SubscriberRepository) FindIgnores(account string) []subscriber.Ignore {
+	r.rwm.RLock()
+	defer r.rwm.RUnlock()
+	return r.Ignores[account]
+}
+
+// IsIgnoring reports whether the account ignores the sender on the chat.
+func (r *SubscriberRepository) IsIgnoring(account string, sender string, chatID string) bool {
+	r.rwm.RLock()
+	defer r.rwm.RUnlock()
+
+	for _, ig := range r.Ignores[account] {
+		if ig.Ignored == sender && ig.AppliesTo(chatID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddIgnore hides the messages of the ignored account from the account.
+func (r *SubscriberRepository) AddIgnore(ig subscriber.Ignore) error {
+	r.rwm.Lock()
+	defer r.rwm.Unlock()
+
+	for _, existing := range r.Ignores[ig.Account] {
+		if existing == ig {
+			return nil
+		}
+	}
+
+	r.Ignores[ig.Account] = append(r.Ignores[ig.Account], ig)
+
+	return nil
+}
+
+// RemoveIgnore shows the messages of the ignored account to the account again.
+func (r *SubscriberRepository) RemoveIgnore(ig subscriber.Ignore) error {
+	r.rwm.Lock()
+	defer r.rwm.Unlock()
+
+	// Copy the ignores since FindIgnores hands out the slice.
+	ignores := make([]subscriber.Ignore, 0, len(r.Ignores[ig.Account]))
+	for _, existing := range r.Ignores[ig.Account] {
+		if existing != ig {
+			ignores = append(ignores, existing)
+		}
+	}
+
+	r.Ignores[ig.Account] = ignores
+
+	return nil
 }
 
 // NewSubscriberRepository returns a repository with all dependencies set up.
 func NewSubscriberRepository() *SubscriberRepository {
 	return &SubscriberRepository{
-		Chats: map[string]map[string]subscriber.Subscriber{
-			"chat":  make(map[string]subscriber.Subscriber),
-			"trade": make(map[string]subscriber.Subscriber),
-			"hc":    make(map[string]subscriber.Subscriber),
-		},
-		Moderators: make([]string, 0),
+		Chats:       make(map[string]map[string]subscriber.Subscriber),
+		Roles:       make(map[string][]role.Role),
+		GameFilters: make(map[string][]game.Filter),
+		Ignores:     make(map[string][]subscriber.Ignore),
 	}
 }
@@ -0,0 +1,56 @@
+package inmem
+
+import (
+	"testing"
+
+	"github.com/nokka/d2-chatbot/internal/subscriber"
+)
+
+func TestIsIgnoring(t *testing.T) {
+	r := NewSubscriberRepository()
+	r.SyncIgnores([]subscriber.Ignore{
+		{Account: "nokka", Chat: "chat", Ignored: "meanski"},
+		{Account: "nokka", Chat: subscriber.AllChats, Ignored: "bruse"},
+	})
+
+	// Adding an ignore twice keeps a single one.
+	r.AddIgnore(subscriber.Ignore{Account: "nokka", Chat: "trade", Ignored: "trog"})
+	r.AddIgnore(subscriber.Ignore{Account: "nokka", Chat: "trade", Ignored: "trog"})
+
+	if ignores := r.FindIgnores("nokka"); len(ignores) != 3 {
+		t.Fatalf("expected 3 ignores, got: %d", len(ignores))
+	}
+
+	tests := []struct {
+		name     string
+		account  string
+		sender   string
+		chatID   string
+		ignoring bool
+	}{
+		{name: "ignored on chat", account: "nokka", sender: "meanski", chatID: "chat", ignoring: true},
+		{name: "not ignored on other chat", account: "nokka", sender: "meanski", chatID: "trade"},
+		{name: "ignored on all chats", account: "nokka", sender: "bruse", chatID: "hc", ignoring: true},
+		{name: "added ignore", account: "nokka", sender: "trog", chatID: "trade", ignoring: true},
+		{name: "not ignored", account: "nokka", sender: "frog", chatID: "chat"},
+		{name: "ignores are one way", account: "meanski", sender: "nokka", chatID: "chat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ignoring := r.IsIgnoring(tt.account, tt.sender, tt.chatID); ignoring != tt.ignoring {
+				t.Fatalf("expected: %v, got: %v", tt.ignoring, ignoring)
+			}
+		})
+	}
+
+	r.RemoveIgnore(subscriber.Ignore{Account: "nokka", Chat: subscriber.AllChats, Ignored: "bruse"})
+
+	if r.IsIgnoring("nokka", "bruse", "hc") {
+		t.Fatal("expected bruse to no longer be ignored")
+	}
+
+	if !r.IsIgnoring("nokka", "meanski", "chat") {
+		t.Fatal("expected meanski to still be ignored")
+	}
+}
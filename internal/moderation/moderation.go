@@ -0,0 +1,28 @@
+package moderation
+
+import "time"
+
+// Action types.
+const (
+	TypeBan             = "ban"
+	TypeUnban           = "unban"
+	TypeGrantModerator  = "mod"
+	TypeRevokeModerator = "unmod"
+	TypeSlowMode        = "slowmode"
+	TypeAnnounce        = "announce"
+)
+
+// Action is a moderation action taken by an actor against a target account,
+// it's kept as an audit trail of everything moderators do.
+type Action struct {
+	Actor string
+	// Target is empty for actions on the whole chat, such as slow mode and announcements.
+	Target string
+	Chat   string
+	Type   string
+	Reason string
+	// Expiry is when the action stops being effective, nil for actions that
+	// never expire such as permanent bans.
+	Expiry    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,140 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/history"
+)
+
+// HistoryRepository is a persistent mysql repository of published messages and queued mentions.
+type HistoryRepository struct {
+	db *sql.DB
+}
+
+// AddMessage persists a published message.
+func (r *HistoryRepository) AddMessage(m history.Message) error {
+	result, err := r.db.Query(`INSERT INTO messages (chat, account, text, created_at) VALUES (?,?,?,?);`, m.Chat, m.Account, m.Text, m.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// FindMessages finds the latest messages published on the chat since the given time, oldest first.
+func (r *HistoryRepository) FindMessages(chatID string, since time.Time, limit int) ([]history.Message, error) {
+	results, err := r.db.Query(`
+	SELECT chat, account, text, created_at FROM (
+		SELECT id, chat, account, text, created_at FROM messages
+			WHERE chat = ? AND created_at > ?
+			ORDER BY id DESC
+			LIMIT ?
+	) AS latest ORDER BY id ASC
+		`, chatID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer results.Close()
+
+	messages := make([]history.Message, 0)
+
+	for results.Next() {
+		var m history.Message
+
+		err = results.Scan(&m.Chat, &m.Account, &m.Text, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, m)
+	}
+
+	return messages, nil
+}
+
+// RemoveMessages removes the messages published on the chat before the given time.
+func (r *HistoryRepository) RemoveMessages(chatID string, before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM messages WHERE chat = ? AND created_at < ?;`, chatID, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// AddMention queues a mention to deliver when the mentioned account logs in.
+func (r *HistoryRepository) AddMention(m history.Mention) error {
+	result, err := r.db.Query(`INSERT INTO mentions (account, chat, sender, text, created_at) VALUES (?,?,?,?,?);`, m.Account, m.Chat, m.Sender, m.Text, m.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// FindMentions finds the latest mentions of the account queued on the chat, oldest first.
+func (r *HistoryRepository) FindMentions(account string, chatID string, limit int) ([]history.Mention, error) {
+	results, err := r.db.Query(`
+	SELECT account, chat, sender, text, created_at FROM (
+		SELECT id, account, chat, sender, text, created_at FROM mentions
+			WHERE account = ? AND chat = ?
+			ORDER BY id DESC
+			LIMIT ?
+	) AS latest ORDER BY id ASC
+		`, account, chatID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer results.Close()
+
+	mentions := make([]history.Mention, 0)
+
+	for results.Next() {
+		var m history.Mention
+
+		err = results.Scan(&m.Account, &m.Chat, &m.Sender, &m.Text, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		mentions = append(mentions, m)
+	}
+
+	return mentions, nil
+}
+
+// RemoveMentions removes all mentions of the account queued on the chat.
+func (r *HistoryRepository) RemoveMentions(account string, chatID string) error {
+	result, err := r.db.Query(`DELETE FROM mentions WHERE account = ? AND chat = ?;`, account, chatID)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// RemoveOldMentions removes the mentions queued on the chat before the given time.
+func (r *HistoryRepository) RemoveOldMentions(chatID string, before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM mentions WHERE chat = ? AND created_at < ?;`, chatID, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// NewHistoryRepository returns a new repository with all dependencies.
+func NewHistoryRepository(db *sql.DB) *HistoryRepository {
+	return &HistoryRepository{
+		db: db,
+	}
+}
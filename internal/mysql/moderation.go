@@ -0,0 +1,67 @@
+package mysql
+
+import (
+	"database/sql"
+
+	"github.com/nokka/d2-chatbot/internal/moderation"
+)
+
+// ModerationRepository is a persistent mysql repository of moderation actions.
+type ModerationRepository struct {
+	db *sql.DB
+}
+
+// LogAction persists a moderation action.
+func (r *ModerationRepository) LogAction(action moderation.Action) error {
+	result, err := r.db.Query(`
+	INSERT INTO moderation_actions (actor, target, chat, action, reason, expires_at, created_at)
+		VALUES (?,?,?,?,?,?,?);`,
+		action.Actor, action.Target, action.Chat, action.Type, action.Reason, action.Expiry, action.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// FindActions finds the latest moderation actions taken against the target, newest first.
+// Actions on a whole chat, such as slow mode and announcements, have an empty target.
+// With a chat only actions on that chat and on all chats are found, otherwise actions on every chat.
+func (r *ModerationRepository) FindActions(target string, chatID string, limit int) ([]moderation.Action, error) {
+	results, err := r.db.Query(`
+	SELECT actor, target, chat, action, reason, expires_at, created_at FROM moderation_actions
+		WHERE target = ? AND (? = '' OR chat = ? OR chat = '*')
+		ORDER BY created_at DESC
+		LIMIT ?
+		`, target, chatID, chatID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	defer results.Close()
+
+	actions := make([]moderation.Action, 0)
+
+	for results.Next() {
+		var action moderation.Action
+
+		err = results.Scan(&action.Actor, &action.Target, &action.Chat, &action.Type, &action.Reason, &action.Expiry, &action.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+// NewModerationRepository returns a new repository with all dependencies.
+func NewModerationRepository(db *sql.DB) *ModerationRepository {
+	return &ModerationRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,59 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/presence"
+)
+
+// PresenceRepository is a persistent mysql repository of account logins and logouts.
+type PresenceRepository struct {
+	db *sql.DB
+}
+
+// UpdateLastLogin sets the time the account last logged in.
+func (r *PresenceRepository) UpdateLastLogin(account string, t time.Time) error {
+	result, err := r.db.Query(`INSERT INTO presence (account, last_login) VALUES (?,?) ON DUPLICATE KEY UPDATE last_login = VALUES(last_login);`, account, t)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// UpdateLastLogout sets the time the account last logged out.
+func (r *PresenceRepository) UpdateLastLogout(account string, t time.Time) error {
+	result, err := r.db.Query(`INSERT INTO presence (account, last_logout) VALUES (?,?) ON DUPLICATE KEY UPDATE last_logout = VALUES(last_logout);`, account, t)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// FindPresence finds the login history of an account, nil if it has never been seen.
+func (r *PresenceRepository) FindPresence(account string) (*presence.Presence, error) {
+	p := presence.Presence{Account: account}
+
+	err := r.db.QueryRow(`SELECT last_login, last_logout FROM presence WHERE account = ?`, account).Scan(&p.LastLogin, &p.LastLogout)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// NewPresenceRepository returns a new repository with all dependencies.
+func NewPresenceRepository(db *sql.DB) *PresenceRepository {
+	return &PresenceRepository{
+		db: db,
+	}
+}
@@ -2,8 +2,11 @@ package mysql
 
 import (
 	"database/sql"
+	"strings"
 	"time"
 
+	"github.com/nokka/d2-chatbot/internal/game"
+	"github.com/nokka/d2-chatbot/internal/role"
 	"github.com/nokka/d2-chatbot/internal/subscriber"
 )
 
@@ -14,7 +17,7 @@ type SubscriberRepository struct {
 
 // FindSubscribers finds all subscribers on a specific chat.
 func (r *SubscriberRepository) FindSubscribers(chatID string) ([]subscriber.Subscriber, error) {
-	results, err := r.db.Query(`SELECT account, online, banned_until FROM subscribers WHERE chat = ?`, chatID)
+	results, err := r.db.Query(`SELECT account, online, banned_until, banned_permanently, digest, mentions_disabled FROM subscribers WHERE chat = ?`, chatID)
 	if err != nil {
 		return nil, err
 	}
@@ -26,7 +29,7 @@ func (r *SubscriberRepository) FindSubscribers(chatID string) ([]subscriber.Subs
 	for results.Next() {
 		var sub subscriber.Subscriber
 
-		err = results.Scan(&sub.Account, &sub.Online, &sub.BannedUntil)
+		err = results.Scan(&sub.Account, &sub.Online, &sub.BannedUntil, &sub.BannedPermanently, &sub.Digest, &sub.MentionsDisabled)
 		if err != nil {
 			return nil, err
 		}
@@ -40,9 +43,10 @@ func (r *SubscriberRepository) FindSubscribers(chatID string) ([]subscriber.Subs
 // FindEligibleSubscribers finds all subscribers eligible to receive chat messages.
 func (r *SubscriberRepository) FindEligibleSubscribers(chatID string) ([]subscriber.Subscriber, error) {
 	results, err := r.db.Query(`
-	SELECT account, online FROM subscribers
+	SELECT account, online, banned_until, banned_permanently, digest, mentions_disabled FROM subscribers
 		WHERE chat = ?
 		AND online = true
+		AND banned_permanently = false
 		AND (banned_until IS NULL OR banned_until <= NOW())
 		`, chatID)
 	if err != nil {
@@ -56,7 +60,7 @@ func (r *SubscriberRepository) FindEligibleSubscribers(chatID string) ([]subscri
 	for results.Next() {
 		var sub subscriber.Subscriber
 
-		err = results.Scan(&sub.Account, &sub.Online, &sub.BannedUntil)
+		err = results.Scan(&sub.Account, &sub.Online, &sub.BannedUntil, &sub.BannedPermanently, &sub.Digest, &sub.MentionsDisabled)
 		if err != nil {
 			return nil, err
 		}
@@ -103,9 +107,104 @@ func (r *SubscriberRepository) UpdateOnlineStatus(account string, online bool) e
 	return nil
 }
 
-// UpdateBannedUntil updates the ban date of an account.
-func (r *SubscriberRepository) UpdateBannedUntil(account string, chatID string, until *time.Time) error {
-	result, err := r.db.Query(`UPDATE subscribers set banned_until = ? WHERE account = ? AND chat = ?;`, until, account, chatID)
+// SyncOnlineStatus marks the given accounts online and every other account offline.
+func (r *SubscriberRepository) SyncOnlineStatus(online []string) error {
+	query := `UPDATE subscribers SET online = false;`
+	args := make([]interface{}, 0, len(online))
+
+	if len(online) > 0 {
+		query = `UPDATE subscribers SET online = account IN (?` + strings.Repeat(`,?`, len(online)-1) + `);`
+		for _, account := range online {
+			args = append(args, account)
+		}
+	}
+
+	result, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// UpdateBan updates the ban date of an account, or bans it permanently.
+func (r *SubscriberRepository) UpdateBan(account string, chatID string, until *time.Time, permanent bool) error {
+	result, err := r.db.Query(`UPDATE subscribers set banned_until = ?, banned_permanently = ? WHERE account = ? AND chat = ?;`, until, permanent, account, chatID)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// UpdateDigest turns the login digest of a subscriber on or off.
+func (r *SubscriberRepository) UpdateDigest(account string, chatID string, enabled bool) error {
+	result, err := r.db.Query(`UPDATE subscribers set digest = ? WHERE account = ? AND chat = ?;`, enabled, account, chatID)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// UpdateMentions turns mention notifications of a subscriber on or off.
+func (r *SubscriberRepository) UpdateMentions(account string, chatID string, enabled bool) error {
+	result, err := r.db.Query(`UPDATE subscribers set mentions_disabled = ? WHERE account = ? AND chat = ?;`, !enabled, account, chatID)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// FindRoles finds all roles.
+func (r *SubscriberRepository) FindRoles() ([]role.Role, error) {
+	results, err := r.db.Query(`SELECT account, chat, role FROM roles`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer results.Close()
+
+	roles := make([]role.Role, 0)
+
+	for results.Next() {
+		var rl role.Role
+
+		err = results.Scan(&rl.Account, &rl.Chat, &rl.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, rl)
+	}
+
+	return roles, nil
+}
+
+// AddRole grants a role to an account.
+func (r *SubscriberRepository) AddRole(rl role.Role) error {
+	result, err := r.db.Query(`INSERT INTO roles (account, chat, role) VALUES (?,?,?) ON DUPLICATE KEY UPDATE account=account;`, rl.Account, rl.Chat, rl.Name)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// RemoveRole revokes a role from an account.
+func (r *SubscriberRepository) RemoveRole(rl role.Role) error {
+	result, err := r.db.Query(`DELETE FROM roles WHERE account = ? AND chat = ? AND role = ?;`, rl.Account, rl.Chat, rl.Name)
 	if err != nil {
 		return err
 	}
@@ -115,29 +214,110 @@ func (r *SubscriberRepository) UpdateBannedUntil(account string, chatID string,
 	return nil
 }
 
-// FindModerators finds all moderators.
-func (r *SubscriberRepository) FindModerators() ([]string, error) {
-	results, err := r.db.Query(`SELECT account FROM moderators`)
+// FindGameFilters finds the game announcement filters of all accounts.
+func (r *SubscriberRepository) FindGameFilters() ([]game.Filter, error) {
+	results, err := r.db.Query(`SELECT account, chat, pattern FROM game_filters`)
 	if err != nil {
 		return nil, err
 	}
 
 	defer results.Close()
 
-	mods := make([]string, 0)
+	filters := make([]game.Filter, 0)
 
 	for results.Next() {
-		var mod string
+		var f game.Filter
 
-		err = results.Scan(&mod)
+		err = results.Scan(&f.Account, &f.Chat, &f.Pattern)
 		if err != nil {
 			return nil, err
 		}
 
-		mods = append(mods, mod)
+		filters = append(filters, f)
+	}
+
+	return filters, nil
+}
+
+// AddGameFilter opts an account into announcements of games matching the filter.
+func (r *SubscriberRepository) AddGameFilter(f game.Filter) error {
+	result, err := r.db.Query(`INSERT INTO game_filters (account, chat, pattern) VALUES (?,?,?) ON DUPLICATE KEY UPDATE account=account;`, f.Account, f.Chat, f.Pattern)
+	if err != nil {
+		return err
 	}
 
-	return mods, nil
+	defer result.Close()
+
+	return nil
+}
+
+// RemoveGameFilters removes the game filter with the pattern, or all filters of the account on the chat if it's empty.
+func (r *SubscriberRepository) RemoveGameFilters(account string, chatID string, pattern string) error {
+	query := `DELETE FROM game_filters WHERE account = ? AND chat = ?`
+	args := []interface{}{account, chatID}
+
+	if pattern != "" {
+		query += ` AND pattern = ?`
+		args = append(args, pattern)
+	}
+
+	result, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// FindIgnores finds the ignores of all accounts.
+func (r *SubscriberRepository) FindIgnores() ([]subscriber.Ignore, error) {
+	results, err := r.db.Query(`SELECT account, chat, ignored FROM ignores`)
+	if err != nil {
+		return nil, err
+	}
+
+	defer results.Close()
+
+	ignores := make([]subscriber.Ignore, 0)
+
+	for results.Next() {
+		var ig subscriber.Ignore
+
+		err = results.Scan(&ig.Account, &ig.Chat, &ig.Ignored)
+		if err != nil {
+			return nil, err
+		}
+
+		ignores = append(ignores, ig)
+	}
+
+	return ignores, nil
+}
+
+// AddIgnore hides the messages of the ignored account from the account.
+func (r *SubscriberRepository) AddIgnore(ig subscriber.Ignore) error {
+	result, err := r.db.Query(`INSERT INTO ignores (account, chat, ignored) VALUES (?,?,?) ON DUPLICATE KEY UPDATE account=account;`, ig.Account, ig.Chat, ig.Ignored)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// RemoveIgnore shows the messages of the ignored account to the account again.
+func (r *SubscriberRepository) RemoveIgnore(ig subscriber.Ignore) error {
+	result, err := r.db.Query(`DELETE FROM ignores WHERE account = ? AND chat = ? AND ignored = ?;`, ig.Account, ig.Chat, ig.Ignored)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
 }
 
 // NewSubscriberRepository returns a new repository with all dependencies.
@@ -0,0 +1,190 @@
+package mysql
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/nokka/d2-chatbot/internal/trade"
+)
+
+// Escapes the LIKE wildcards in search terms.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// TradeRepository is a persistent mysql repository of trade listings and keyword watches.
+type TradeRepository struct {
+	db *sql.DB
+}
+
+// AddListing persists a listing and returns its id and whether it's new, listing
+// the same items again renews the existing listing instead.
+func (r *TradeRepository) AddListing(l trade.Listing) (int64, bool, error) {
+	result, err := r.db.Exec(`
+	INSERT INTO trade_listings (account, chat, type, items, created_at, expires_at)
+		VALUES (?,?,?,?,?,?)
+		ON DUPLICATE KEY UPDATE id=LAST_INSERT_ID(id), created_at=VALUES(created_at), expires_at=VALUES(expires_at);`,
+		l.Account, l.Chat, l.Type, l.Items, l.CreatedAt, l.ExpiresAt,
+	)
+	if err != nil {
+		return 0, false, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, false, err
+	}
+
+	// Inserted rows count as 1 affected row, updated ones as 2.
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, false, err
+	}
+
+	return id, n == 1, nil
+}
+
+// FindListings finds the newest active listings on the chat containing every term.
+func (r *TradeRepository) FindListings(chatID string, terms []string, now time.Time, limit int) ([]trade.Listing, error) {
+	query := `SELECT id, account, chat, type, items, created_at, expires_at FROM trade_listings
+		WHERE chat = ? AND expires_at > ?`
+	args := []interface{}{chatID, now}
+
+	for _, term := range terms {
+		query += ` AND items LIKE ?`
+		args = append(args, "%"+likeEscaper.Replace(term)+"%")
+	}
+
+	query += ` ORDER BY created_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	return r.findListings(query, args...)
+}
+
+// FindAccountListings finds the active listings of the account on the chat, newest first.
+func (r *TradeRepository) FindAccountListings(account string, chatID string, now time.Time) ([]trade.Listing, error) {
+	return r.findListings(`
+	SELECT id, account, chat, type, items, created_at, expires_at FROM trade_listings
+		WHERE account = ? AND chat = ? AND expires_at > ?
+		ORDER BY created_at DESC
+		`, account, chatID, now)
+}
+
+// RemoveListing removes the listing if it belongs to the account, reporting whether it did.
+func (r *TradeRepository) RemoveListing(id int64, account string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM trade_listings WHERE id = ? AND account = ?;`, id, account)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n > 0, nil
+}
+
+// RemoveExpiredListings removes all listings that expired before now.
+func (r *TradeRepository) RemoveExpiredListings(now time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM trade_listings WHERE expires_at <= ?;`, now)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// FindWatches finds the keyword watches of every account on the chat.
+func (r *TradeRepository) FindWatches(chatID string) ([]trade.Watch, error) {
+	return r.findWatches(`SELECT account, chat, keyword FROM watches WHERE chat = ?`, chatID)
+}
+
+// FindAccountWatches finds the keyword watches of the account on the chat.
+func (r *TradeRepository) FindAccountWatches(account string, chatID string) ([]trade.Watch, error) {
+	return r.findWatches(`SELECT account, chat, keyword FROM watches WHERE account = ? AND chat = ? ORDER BY keyword`, account, chatID)
+}
+
+// AddWatch persists a keyword watch.
+func (r *TradeRepository) AddWatch(w trade.Watch) error {
+	result, err := r.db.Query(`INSERT INTO watches (account, chat, keyword) VALUES (?,?,?) ON DUPLICATE KEY UPDATE account=account;`, w.Account, w.Chat, w.Keyword)
+	if err != nil {
+		return err
+	}
+
+	defer result.Close()
+
+	return nil
+}
+
+// RemoveWatches removes the keyword watch, or all watches of the account on the chat if the keyword is empty.
+func (r *TradeRepository) RemoveWatches(account string, chatID string, keyword string) (int64, error) {
+	query := `DELETE FROM watches WHERE account = ? AND chat = ?`
+	args := []interface{}{account, chatID}
+
+	if keyword != "" {
+		query += ` AND keyword = ?`
+		args = append(args, keyword)
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *TradeRepository) findWatches(query string, args ...interface{}) ([]trade.Watch, error) {
+	results, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer results.Close()
+
+	watches := make([]trade.Watch, 0)
+
+	for results.Next() {
+		var w trade.Watch
+
+		err = results.Scan(&w.Account, &w.Chat, &w.Keyword)
+		if err != nil {
+			return nil, err
+		}
+
+		watches = append(watches, w)
+	}
+
+	return watches, nil
+}
+
+func (r *TradeRepository) findListings(query string, args ...interface{}) ([]trade.Listing, error) {
+	results, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer results.Close()
+
+	listings := make([]trade.Listing, 0)
+
+	for results.Next() {
+		var l trade.Listing
+
+		err = results.Scan(&l.ID, &l.Account, &l.Chat, &l.Type, &l.Items, &l.CreatedAt, &l.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+
+		listings = append(listings, l)
+	}
+
+	return listings, nil
+}
+
+// NewTradeRepository returns a new repository with all dependencies.
+func NewTradeRepository(db *sql.DB) *TradeRepository {
+	return &TradeRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,19 @@
+package presence
+
+import "time"
+
+// Presence is the login history of an account observed on the server.
+type Presence struct {
+	Account    string
+	LastLogin  *time.Time
+	LastLogout *time.Time
+}
+
+// Online reports whether the account is logged in, according to the latest login and logout.
+func (p Presence) Online() bool {
+	if p.LastLogin == nil {
+		return false
+	}
+
+	return p.LastLogout == nil || p.LastLogin.After(*p.LastLogout)
+}
@@ -0,0 +1,67 @@
+package role
+
+// Roles.
+const (
+	Moderator = "moderator"
+	Admin     = "admin"
+)
+
+// Permissions granted by roles.
+const (
+	PermissionBan          = "ban"
+	PermissionUnban        = "unban"
+	PermissionAnnounce     = "announce"
+	PermissionManageMods   = "manage-mods"
+	PermissionSlowMode     = "slowmode"
+	PermissionModLog       = "modlog"
+	PermissionBypassLimits = "bypass-limits"
+	PermissionViewMembers  = "view-members"
+)
+
+// AllChats is the chat of roles that apply on every chat.
+const AllChats = "*"
+
+// permissions maps every role to the permissions it grants.
+var permissions = map[string][]string{
+	Moderator: {
+		PermissionBan,
+		PermissionUnban,
+		PermissionSlowMode,
+		PermissionModLog,
+		PermissionBypassLimits,
+		PermissionViewMembers,
+	},
+	Admin: {
+		PermissionBan,
+		PermissionUnban,
+		PermissionAnnounce,
+		PermissionManageMods,
+		PermissionSlowMode,
+		PermissionModLog,
+		PermissionBypassLimits,
+		PermissionViewMembers,
+	},
+}
+
+// Role is a named role held by an account on a chat.
+type Role struct {
+	Account string
+	Chat    string
+	Name    string
+}
+
+// AppliesTo reports whether the role is effective on the given chat.
+func (r Role) AppliesTo(chatID string) bool {
+	return r.Chat == chatID || r.Chat == AllChats
+}
+
+// Has reports whether the role grants the permission.
+func (r Role) Has(permission string) bool {
+	for _, p := range permissions[r.Name] {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
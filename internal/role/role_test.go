@@ -0,0 +1,49 @@
+package role
+
+import "testing"
+
+func TestAppliesTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    Role
+		chatID  string
+		applies bool
+	}{
+		{name: "same chat", role: Role{Chat: "chat"}, chatID: "chat", applies: true},
+		{name: "other chat", role: Role{Chat: "chat"}, chatID: "trade", applies: false},
+		{name: "all chats", role: Role{Chat: AllChats}, chatID: "trade", applies: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if applies := tt.role.AppliesTo(tt.chatID); applies != tt.applies {
+				t.Fatalf("expected: %v, got: %v", tt.applies, applies)
+			}
+		})
+	}
+}
+
+func TestHas(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       string
+		permission string
+		has        bool
+	}{
+		{name: "moderator ban", role: Moderator, permission: PermissionBan, has: true},
+		{name: "moderator announce", role: Moderator, permission: PermissionAnnounce, has: false},
+		{name: "moderator manage mods", role: Moderator, permission: PermissionManageMods, has: false},
+		{name: "admin announce", role: Admin, permission: PermissionAnnounce, has: true},
+		{name: "admin manage mods", role: Admin, permission: PermissionManageMods, has: true},
+		{name: "unknown role", role: "founder", permission: PermissionBan, has: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Role{Account: "nokka", Chat: "chat", Name: tt.role}
+			if has := r.Has(tt.permission); has != tt.has {
+				t.Fatalf("expected: %v, got: %v", tt.has, has)
+			}
+		})
+	}
+}
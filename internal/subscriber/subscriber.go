@@ -5,7 +5,34 @@ import "time"
 // Subscriber is the heart of the domain, a subscriber
 // represents an account and it's current state.
 type Subscriber struct {
-	Account     string
-	Online      bool
-	BannedUntil *time.Time
+	Account           string
+	Online            bool
+	BannedUntil       *time.Time
+	BannedPermanently bool
+	Digest            bool
+	MentionsDisabled  bool
+}
+
+// Banned reports whether the subscriber is banned at the given time.
+func (s Subscriber) Banned(now time.Time) bool {
+	if s.BannedPermanently {
+		return true
+	}
+
+	return s.BannedUntil != nil && s.BannedUntil.After(now)
+}
+
+// AllChats is the chat of ignores that apply on every chat.
+const AllChats = "*"
+
+// Ignore hides the messages of the ignored account from the account, on a single chat or on all of them.
+type Ignore struct {
+	Account string
+	Chat    string
+	Ignored string
+}
+
+// AppliesTo reports whether the ignore is effective on the given chat.
+func (i Ignore) AppliesTo(chatID string) bool {
+	return i.Chat == chatID || i.Chat == AllChats
 }
@@ -0,0 +1,24 @@
+package subscriber
+
+import "testing"
+
+func TestIgnoreAppliesTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		ignore  Ignore
+		chatID  string
+		applies bool
+	}{
+		{name: "same chat", ignore: Ignore{Chat: "chat"}, chatID: "chat", applies: true},
+		{name: "other chat", ignore: Ignore{Chat: "chat"}, chatID: "trade", applies: false},
+		{name: "all chats", ignore: Ignore{Chat: AllChats}, chatID: "trade", applies: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if applies := tt.ignore.AppliesTo(tt.chatID); applies != tt.applies {
+				t.Fatalf("expected: %v, got: %v", tt.applies, applies)
+			}
+		})
+	}
+}
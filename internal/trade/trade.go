@@ -0,0 +1,109 @@
+package trade
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Listing types.
+const (
+	TypeSell  = "wts"
+	TypeBuy   = "wtb"
+	TypeTrade = "wtt"
+)
+
+// MaxItemsLength is the max length of the items of a listing.
+const MaxItemsLength = 255
+
+// Marker starting a listing in a post, such as "WTS", "[WTB]" or "wtt:".
+var markerRegex = regexp.MustCompile(`(?i)(?:^|[\s\[(])(wts|wtb|wtt)\b[\]):]*`)
+
+// Listing is an item wanted or offered on the trade channel.
+type Listing struct {
+	ID        int64
+	Account   string
+	Chat      string
+	Type      string
+	Items     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Parse finds the listings in a post, a post can hold several such as
+// "WTS shako, enigma WTB ber", which is a listing of each type.
+func Parse(text string) []Listing {
+	markers := markerRegex.FindAllStringSubmatchIndex(text, -1)
+
+	listings := make([]Listing, 0, len(markers))
+	for i, m := range markers {
+		end := len(text)
+		if i+1 < len(markers) {
+			end = markers[i+1][0]
+		}
+
+		items := strings.Trim(text[m[1]:end], " ,;|-/")
+		if items == "" {
+			continue
+		}
+
+		if len(items) > MaxItemsLength {
+			// Cut at the start of a character so it isn't split.
+			cut := MaxItemsLength
+			for cut > 0 && !utf8.RuneStart(items[cut]) {
+				cut--
+			}
+
+			items = items[:cut]
+		}
+
+		listings = append(listings, Listing{
+			Type:  strings.ToLower(text[m[2]:m[3]]),
+			Items: items,
+		})
+	}
+
+	return listings
+}
+
+// Watch alerts the account of posts containing the keyword.
+type Watch struct {
+	Account string
+	Chat    string
+	Keyword string
+}
+
+// Matches reports whether the text contains the keyword as a whole word, ignoring case.
+func (w Watch) Matches(text string) bool {
+	keyword := strings.ToLower(w.Keyword)
+	text = strings.ToLower(text)
+
+	if keyword == "" {
+		return false
+	}
+
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], keyword)
+		if i < 0 {
+			return false
+		}
+
+		start := offset + i
+		if !isWordByte(text, start-1) && !isWordByte(text, start+len(keyword)) {
+			return true
+		}
+
+		offset = start + 1
+	}
+}
+
+// isWordByte reports whether the byte at i is part of a word, out of bounds isn't.
+func isWordByte(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+
+	b := text[i]
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '\''
+}
@@ -0,0 +1,97 @@
+package trade
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		listings []Listing
+	}{
+		{
+			name:     "sell",
+			input:    "WTS shako, enigma",
+			listings: []Listing{{Type: TypeSell, Items: "shako, enigma"}},
+		},
+		{
+			name:     "buy in brackets",
+			input:    "[WTB] ber rune",
+			listings: []Listing{{Type: TypeBuy, Items: "ber rune"}},
+		},
+		{
+			name:     "trade with colon",
+			input:    "wtt: jah for ber",
+			listings: []Listing{{Type: TypeTrade, Items: "jah for ber"}},
+		},
+		{
+			name:  "sell and buy",
+			input: "WTS shako, enigma WTB ber",
+			listings: []Listing{
+				{Type: TypeSell, Items: "shako, enigma"},
+				{Type: TypeBuy, Items: "ber"},
+			},
+		},
+		{
+			name:     "marker inside a word",
+			input:    "anyone got wtsword?",
+			listings: []Listing{},
+		},
+		{
+			name:     "marker without items",
+			input:    "wts",
+			listings: []Listing{},
+		},
+		{
+			name:     "free text",
+			input:    "anyone up for baal runs?",
+			listings: []Listing{},
+		},
+		{
+			name:     "long items",
+			input:    "WTS " + strings.Repeat("a", 300),
+			listings: []Listing{{Type: TypeSell, Items: strings.Repeat("a", MaxItemsLength)}},
+		},
+		{
+			name:     "long items cut before a character",
+			input:    "WTS " + strings.Repeat("a", MaxItemsLength-1) + "é",
+			listings: []Listing{{Type: TypeSell, Items: strings.Repeat("a", MaxItemsLength-1)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if listings := Parse(tt.input); !reflect.DeepEqual(tt.listings, listings) {
+				t.Fatalf("expected: %v, got: %v", tt.listings, listings)
+			}
+		})
+	}
+}
+
+func TestWatchMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyword string
+		text    string
+		matches bool
+	}{
+		{name: "word", keyword: "shako", text: "WTS shako, enigma", matches: true},
+		{name: "ignores case", keyword: "Shako", text: "wts SHAKO", matches: true},
+		{name: "phrase", keyword: "grand charm", text: "WTB 20 life grand charm", matches: true},
+		{name: "inside a word", keyword: "ber", text: "WTS number of jewels", matches: false},
+		{name: "later as a word", keyword: "ber", text: "WTS number of jewels, ber", matches: true},
+		{name: "missing", keyword: "enigma", text: "WTS shako", matches: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := Watch{Keyword: tt.keyword}
+			if matches := w.Matches(tt.text); matches != tt.matches {
+				t.Fatalf("expected matches = %v; got = %v", tt.matches, matches)
+			}
+		})
+	}
+}